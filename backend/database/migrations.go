@@ -0,0 +1,553 @@
+package database
+
+import (
+	"fmt"
+	"log"
+)
+
+type migration struct {
+	Version    int
+	Name       string
+	Statements []string
+	// Transactional migrations only change data. Their statements and the
+	// version record run in one transaction, so a failed run leaves nothing
+	// behind and can simply be retried.
+	Transactional bool
+}
+
+// migrations are applied in order, exactly once per database. Never edit a
+// migration that has shipped; append a new one instead.
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "wallet ledger",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS wallet_transactions (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				entry_id CHAR(32) NOT NULL,
+				account VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				type ENUM('debit','credit') NOT NULL,
+				amount BIGINT NOT NULL,
+				game VARCHAR(64) DEFAULT NULL,
+				round_id VARCHAR(64) DEFAULT NULL,
+				reason VARCHAR(64) NOT NULL,
+				balance_after BIGINT DEFAULT NULL,
+				created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+				PRIMARY KEY (id),
+				KEY idx_wallet_tx_entry (entry_id),
+				KEY idx_wallet_tx_user (user_id, account, id),
+				KEY idx_wallet_tx_round (round_id),
+				CONSTRAINT fk_wallet_tx_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			// Opening balances so the ledger agrees with users.balance from day one.
+			`INSERT INTO wallet_transactions (entry_id, account, user_id, type, amount, reason, balance_after)
+			SELECT MD5(CONCAT('opening-', id)), 'player', id, IF(balance >= 0, 'credit', 'debit'), ABS(balance), 'opening_balance', balance
+			FROM users WHERE balance <> 0`,
+			`INSERT INTO wallet_transactions (entry_id, account, user_id, type, amount, reason)
+			SELECT MD5(CONCAT('opening-', id)), 'house', id, IF(balance >= 0, 'debit', 'credit'), ABS(balance), 'opening_balance'
+			FROM users WHERE balance <> 0`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "idempotency keys",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS idempotency_keys (
+				user_id INT NOT NULL,
+				idem_key VARCHAR(255) NOT NULL,
+				method VARCHAR(10) NOT NULL,
+				path VARCHAR(255) NOT NULL,
+				status_code INT DEFAULT NULL,
+				content_type VARCHAR(255) DEFAULT NULL,
+				body MEDIUMBLOB,
+				created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				expires_at DATETIME NOT NULL,
+				PRIMARY KEY (user_id, idem_key),
+				KEY idx_idempotency_expires (expires_at)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "wallet history index",
+		Statements: []string{
+			`CREATE INDEX idx_wallet_tx_user_created ON wallet_transactions (user_id, account, created_at)`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "bonus sub-wallet",
+		Statements: []string{
+			`ALTER TABLE users ADD COLUMN bonus_balance BIGINT NOT NULL DEFAULT 0 AFTER balance`,
+			`ALTER TABLE wallet_transactions ADD COLUMN sub_wallet ENUM('cash','bonus') NOT NULL DEFAULT 'cash' AFTER reason`,
+			`CREATE TABLE IF NOT EXISTS bonus_grants (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				user_id INT NOT NULL,
+				source VARCHAR(64) NOT NULL,
+				amount BIGINT NOT NULL,
+				remaining BIGINT NOT NULL,
+				wagering_required BIGINT NOT NULL,
+				wagered BIGINT NOT NULL DEFAULT 0,
+				status ENUM('active','converted','exhausted') NOT NULL DEFAULT 'active',
+				created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				completed_at DATETIME DEFAULT NULL,
+				PRIMARY KEY (id),
+				KEY idx_bonus_grants_user (user_id, status, id),
+				CONSTRAINT fk_bonus_grants_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "multi-currency wallets",
+		Statements: []string{
+			`ALTER TABLE users MODIFY COLUMN balance BIGINT NOT NULL DEFAULT 0`,
+			`CREATE TABLE IF NOT EXISTS wallet_balances (
+				user_id INT NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				balance BIGINT NOT NULL DEFAULT 0,
+				bonus_balance BIGINT NOT NULL DEFAULT 0,
+				PRIMARY KEY (user_id, currency),
+				CONSTRAINT fk_wallet_balances_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			// Everything held so far is gold coins.
+			`INSERT INTO wallet_balances (user_id, currency, balance, bonus_balance)
+			SELECT id, 'GC', balance, bonus_balance FROM users`,
+			`ALTER TABLE wallet_transactions ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'GC' AFTER amount`,
+			`CREATE INDEX idx_wallet_tx_user_currency ON wallet_transactions (user_id, account, currency, id)`,
+			`ALTER TABLE bonus_grants ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'GC' AFTER user_id`,
+			`CREATE INDEX idx_bonus_grants_currency ON bonus_grants (user_id, currency, status, id)`,
+			`CREATE TABLE IF NOT EXISTS redemptions (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				user_id INT NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				amount BIGINT NOT NULL,
+				status ENUM('pending','paid','rejected') NOT NULL DEFAULT 'pending',
+				created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				processed_at DATETIME DEFAULT NULL,
+				PRIMARY KEY (id),
+				KEY idx_redemptions_user (user_id, id),
+				CONSTRAINT fk_redemptions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "money in minor units",
+		// Every stored amount moves from whole coins to hundredths of a coin
+		// (see package money). The updates are not idempotent, so they must
+		// commit together with the version record.
+		Transactional: true,
+		Statements: []string{
+			`UPDATE users SET balance = balance * 100, bonus_balance = bonus_balance * 100`,
+			`UPDATE wallet_balances SET balance = balance * 100, bonus_balance = bonus_balance * 100`,
+			`UPDATE wallet_transactions SET amount = amount * 100, balance_after = balance_after * 100`,
+			`UPDATE bonus_grants SET amount = amount * 100, remaining = remaining * 100,
+				wagering_required = wagering_required * 100, wagered = wagered * 100`,
+			`UPDATE redemptions SET amount = amount * 100`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "responsible gaming limits",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS player_limits (
+				user_id INT NOT NULL,
+				currency VARCHAR(8) NOT NULL DEFAULT '',
+				limit_type VARCHAR(16) NOT NULL,
+				period VARCHAR(16) NOT NULL DEFAULT '',
+				value BIGINT NOT NULL DEFAULT 0,
+				pending_value BIGINT DEFAULT NULL,
+				pending_effective_at DATETIME DEFAULT NULL,
+				updated_at DATETIME NOT NULL,
+				PRIMARY KEY (user_id, currency, limit_type, period),
+				CONSTRAINT fk_player_limits_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS play_sessions (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				user_id INT NOT NULL,
+				started_at DATETIME NOT NULL,
+				last_activity_at DATETIME NOT NULL,
+				PRIMARY KEY (id),
+				KEY idx_play_sessions_user (user_id, id),
+				CONSTRAINT fk_play_sessions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "self-exclusion",
+		Statements: []string{
+			// Append-only: rows are never updated or deleted by the application.
+			`CREATE TABLE IF NOT EXISTS self_exclusions (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				user_id INT NOT NULL,
+				period VARCHAR(16) NOT NULL,
+				starts_at DATETIME NOT NULL,
+				ends_at DATETIME DEFAULT NULL,
+				created_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				KEY idx_self_exclusions_user (user_id, ends_at),
+				CONSTRAINT fk_self_exclusions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "reality checks",
+		Statements: []string{
+			// reality_check_at is the last acknowledgement; NULL means the
+			// interval runs from started_at.
+			`ALTER TABLE play_sessions
+				ADD COLUMN reality_check_at DATETIME DEFAULT NULL AFTER last_activity_at,
+				ADD COLUMN reality_check_pending TINYINT(1) NOT NULL DEFAULT 0 AFTER reality_check_at`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "blackjack rounds",
+		Statements: []string{
+			// id is the wallet round id, so a hand can be matched to its bet
+			// and payout in wallet_transactions.
+			`CREATE TABLE IF NOT EXISTS blackjack_rounds (
+				id VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				bet BIGINT NOT NULL,
+				deck JSON NOT NULL,
+				player_cards JSON NOT NULL,
+				dealer_cards JSON NOT NULL,
+				status ENUM('active','finished') NOT NULL DEFAULT 'active',
+				win_amount BIGINT NOT NULL DEFAULT 0,
+				message VARCHAR(255) NOT NULL DEFAULT '',
+				created_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL,
+				PRIMARY KEY (id),
+				KEY idx_blackjack_rounds_user (user_id, status),
+				CONSTRAINT fk_blackjack_rounds_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "blackjack hands",
+		Statements: []string{
+			`ALTER TABLE blackjack_rounds
+				ADD COLUMN hands JSON DEFAULT NULL AFTER deck,
+				ADD COLUMN active_hand INT NOT NULL DEFAULT 0 AFTER hands,
+				ADD COLUMN insurance BIGINT NOT NULL DEFAULT 0 AFTER dealer_cards,
+				ADD COLUMN insurance_state VARCHAR(16) NOT NULL DEFAULT '' AFTER insurance`,
+			// Rounds so far have a single hand; bets are encoded the way
+			// money.Amount marshals them.
+			`UPDATE blackjack_rounds
+			SET hands = JSON_ARRAY(JSON_OBJECT(
+				'cards', player_cards,
+				'bet', CAST(bet / 100 AS DECIMAL(20, 2)),
+				'status', IF(status = 'active', 'playing', 'stood')))`,
+			`ALTER TABLE blackjack_rounds MODIFY COLUMN hands JSON NOT NULL, DROP COLUMN player_cards`,
+		},
+	},
+	{
+		Version: 12,
+		Name:    "blackjack tables",
+		Statements: []string{
+			`ALTER TABLE blackjack_rounds
+				ADD COLUMN table_id VARCHAR(32) NOT NULL DEFAULT 'classic' AFTER currency,
+				ADD COLUMN rules JSON DEFAULT NULL AFTER table_id,
+				ADD KEY idx_blackjack_rounds_table (table_id, created_at)`,
+			// Earlier rounds were all dealt under the classic rules.
+			`UPDATE blackjack_rounds
+			SET rules = '{"table":"classic","name":"Classic","decks":1,"hitSoft17":false,"blackjackPays":"3:2","doubleAfterSplit":true,"maxSplits":3,"minBet":1.00,"maxBet":0.00}'`,
+			`ALTER TABLE blackjack_rounds MODIFY COLUMN rules JSON NOT NULL`,
+		},
+	},
+	{
+		Version: 13,
+		Name:    "card shoes",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS shoes (
+				table_id VARCHAR(64) NOT NULL,
+				decks INT NOT NULL,
+				cards JSON NOT NULL,
+				position INT NOT NULL DEFAULT 0,
+				cut_card INT NOT NULL,
+				shuffled_at DATETIME NOT NULL,
+				updated_at DATETIME NOT NULL,
+				PRIMARY KEY (table_id)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			// Blackjack rounds now draw from their table's shoe.
+			`ALTER TABLE blackjack_rounds DROP COLUMN deck`,
+		},
+	},
+	{
+		Version: 14,
+		Name:    "baccarat rounds",
+		Statements: []string{
+			`ALTER TABLE shoes ADD COLUMN shoe_number INT NOT NULL DEFAULT 1 AFTER table_id`,
+			`CREATE TABLE IF NOT EXISTS baccarat_rounds (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				round_id VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				table_id VARCHAR(64) NOT NULL,
+				variant VARCHAR(32) NOT NULL,
+				shoe_number INT NOT NULL,
+				winner ENUM('PLAYER','BANKER','TIE') NOT NULL,
+				player_total TINYINT NOT NULL,
+				banker_total TINYINT NOT NULL,
+				player_pair TINYINT(1) NOT NULL,
+				banker_pair TINYINT(1) NOT NULL,
+				is_natural TINYINT(1) NOT NULL,
+				created_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				UNIQUE KEY uq_baccarat_rounds_round (round_id),
+				KEY idx_baccarat_rounds_shoe (table_id, shoe_number, id),
+				CONSTRAINT fk_baccarat_rounds_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 15,
+		Name:    "roulette spins",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS roulette_spins (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				round_id VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				variant VARCHAR(32) NOT NULL,
+				number TINYINT NOT NULL,
+				color ENUM('red','black','green') NOT NULL,
+				prison_number TINYINT NULL,
+				created_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				UNIQUE KEY uq_roulette_spins_round (round_id),
+				KEY idx_roulette_spins_variant (variant, id),
+				CONSTRAINT fk_roulette_spins_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 16,
+		Name:    "hilo rounds",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS hilo_rounds (
+				id VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				bet BIGINT NOT NULL,
+				pot BIGINT NOT NULL,
+				streak INT NOT NULL DEFAULT 0,
+				current_card JSON NOT NULL,
+				deck JSON NOT NULL,
+				position INT NOT NULL,
+				status ENUM('active','cashed_out','lost') NOT NULL DEFAULT 'active',
+				created_at DATETIME(6) NOT NULL,
+				updated_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				KEY idx_hilo_rounds_user (user_id, status),
+				CONSTRAINT fk_hilo_rounds_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 17,
+		Name:    "jackpot pools",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS jackpot_pools (
+				pool VARCHAR(64) NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				amount BIGINT NOT NULL,
+				updated_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (pool, currency)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS jackpot_wins (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				pool VARCHAR(64) NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				user_id INT NOT NULL,
+				round_id VARCHAR(64) DEFAULT NULL,
+				amount BIGINT NOT NULL,
+				created_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				KEY idx_jackpot_wins_pool (pool, currency, id),
+				CONSTRAINT fk_jackpot_wins_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 18,
+		Name:    "live keno draws",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS keno_draws (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				draw_at DATETIME NOT NULL,
+				status ENUM('open','drawn') NOT NULL DEFAULT 'open',
+				numbers JSON DEFAULT NULL,
+				drawn_at DATETIME(6) DEFAULT NULL,
+				PRIMARY KEY (id),
+				UNIQUE KEY uq_keno_draws_at (draw_at),
+				KEY idx_keno_draws_status (status, draw_at)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS keno_tickets (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				round_id VARCHAR(64) NOT NULL,
+				user_id INT NOT NULL,
+				draw_id BIGINT NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				numbers JSON NOT NULL,
+				bet BIGINT NOT NULL,
+				status ENUM('pending','settled') NOT NULL DEFAULT 'pending',
+				hits TINYINT DEFAULT NULL,
+				payout BIGINT NOT NULL DEFAULT 0,
+				jackpot_won TINYINT(1) NOT NULL DEFAULT 0,
+				created_at DATETIME(6) NOT NULL,
+				settled_at DATETIME(6) DEFAULT NULL,
+				PRIMARY KEY (id),
+				KEY idx_keno_tickets_user (user_id, id),
+				KEY idx_keno_tickets_draw (draw_id, status),
+				KEY idx_keno_tickets_round (round_id),
+				CONSTRAINT fk_keno_tickets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+				CONSTRAINT fk_keno_tickets_draw FOREIGN KEY (draw_id) REFERENCES keno_draws (id)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 19,
+		Name:    "keno number sets",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS keno_sets (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				user_id INT NOT NULL,
+				name VARCHAR(64) NOT NULL,
+				numbers JSON NOT NULL,
+				created_at DATETIME(6) NOT NULL,
+				updated_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				UNIQUE KEY uq_keno_sets_name (user_id, name),
+				CONSTRAINT fk_keno_sets_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 20,
+		Name:    "mystery jackpots",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS mystery_jackpots (
+				tier VARCHAR(16) NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				amount BIGINT NOT NULL,
+				drop_at BIGINT NOT NULL,
+				updated_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (tier, currency)
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+			`CREATE TABLE IF NOT EXISTS mystery_jackpot_wins (
+				id BIGINT NOT NULL AUTO_INCREMENT,
+				tier VARCHAR(16) NOT NULL,
+				currency VARCHAR(8) NOT NULL,
+				user_id INT NOT NULL,
+				game VARCHAR(64) NOT NULL,
+				round_id VARCHAR(64) NOT NULL,
+				amount BIGINT NOT NULL,
+				created_at DATETIME(6) NOT NULL,
+				PRIMARY KEY (id),
+				KEY idx_mystery_jackpot_wins_currency (currency, id),
+				CONSTRAINT fk_mystery_jackpot_wins_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+			) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+		},
+	},
+	{
+		Version: 21,
+		Name:    "blackjack round expiry",
+		Statements: []string{
+			// Open rounds are looked up by table and age whenever a shoe is
+			// dealt from, and expired by age.
+			`CREATE INDEX idx_blackjack_rounds_open ON blackjack_rounds (table_id, status, updated_at)`,
+			`CREATE INDEX idx_blackjack_rounds_idle ON blackjack_rounds (status, updated_at)`,
+		},
+	},
+	{
+		Version: 22,
+		Name:    "void keno tickets",
+		Statements: []string{
+			// A ticket that cannot be settled is refunded and voided rather
+			// than holding up its draw.
+			`ALTER TABLE keno_tickets MODIFY status ENUM('pending','settled','void') NOT NULL DEFAULT 'pending'`,
+		},
+	},
+}
+
+// Migrate brings the schema up to date. It is safe to call on every start.
+func Migrate() error {
+	_, err := DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (version)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	applied := map[int]bool{}
+	rows, err := DB.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[v] = true
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if applied[m.Version] {
+			continue
+		}
+		if m.Transactional {
+			if err := applyTx(m); err != nil {
+				return err
+			}
+			log.Printf("✅ Applied migration %d: %s\n", m.Version, m.Name)
+			continue
+		}
+		// MySQL commits DDL implicitly, so statements run one by one and the
+		// version is only recorded once all of them succeeded.
+		for _, stmt := range m.Statements {
+			if _, err := DB.Exec(stmt); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err := DB.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			return fmt.Errorf("record migration %d: %w", m.Version, err)
+		}
+		log.Printf("✅ Applied migration %d: %s\n", m.Version, m.Name)
+	}
+	return nil
+}
+
+// applyTx runs a transactional migration and records its version in the same
+// transaction.
+func applyTx(m migration) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.Statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+		return fmt.Errorf("record migration %d: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package exclusion records player self-exclusions.
+//
+// self_exclusions is append-only: an exclusion is never shortened, lifted or
+// deleted, and every request is kept with its timestamp. A player can only
+// replace an active exclusion with one that ends later.
+package exclusion
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	Day       = "24h"
+	Week      = "7d"
+	HalfYear  = "6m"
+	Permanent = "permanent"
+)
+
+var (
+	ErrInvalidPeriod = errors.New("exclusion: period must be 24h, 7d, 6m or permanent")
+	// ErrAlreadyExcluded is returned when the requested exclusion would end
+	// before the one already in force.
+	ErrAlreadyExcluded = errors.New("exclusion: an exclusion ending later is already active")
+)
+
+// End returns when an exclusion of period starting at start ends. ok is
+// false for permanent exclusions.
+func End(period string, start time.Time) (end time.Time, ok bool, err error) {
+	switch period {
+	case Day:
+		return start.Add(24 * time.Hour), true, nil
+	case Week:
+		return start.AddDate(0, 0, 7), true, nil
+	case HalfYear:
+		return start.AddDate(0, 6, 0), true, nil
+	case Permanent:
+		return time.Time{}, false, nil
+	}
+	return time.Time{}, false, ErrInvalidPeriod
+}
+
+const columns = "id, period, starts_at, ends_at, created_at"
+
+// Active returns the exclusion in force for a user at now, or nil.
+func Active(userID int, now time.Time) (*models.SelfExclusion, error) {
+	return active(database.DB.QueryRow(activeQuery, userID, now, now))
+}
+
+const activeQuery = `
+	SELECT ` + columns + ` FROM self_exclusions
+	WHERE user_id = ? AND starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)
+	ORDER BY ends_at IS NULL DESC, ends_at DESC
+	LIMIT 1`
+
+func active(row *sql.Row) (*models.SelfExclusion, error) {
+	e, err := scan(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scan(s scanner) (models.SelfExclusion, error) {
+	var e models.SelfExclusion
+	var endsAt sql.NullTime
+	if err := s.Scan(&e.ID, &e.Period, &e.StartsAt, &endsAt, &e.CreatedAt); err != nil {
+		return e, err
+	}
+	if endsAt.Valid {
+		t := endsAt.Time
+		e.EndsAt = &t
+	}
+	return e, nil
+}
+
+// Exclude starts a self-exclusion for period right away.
+func Exclude(userID int, period string) (models.SelfExclusion, error) {
+	now := time.Now()
+	end, finite, err := End(period, now)
+	if err != nil {
+		return models.SelfExclusion{}, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return models.SelfExclusion{}, err
+	}
+	defer tx.Rollback()
+
+	// Lock the user so two requests cannot both pass the check below.
+	var id int
+	if err := tx.QueryRow("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&id); err != nil {
+		return models.SelfExclusion{}, err
+	}
+	current, err := active(tx.QueryRow(activeQuery, userID, now, now))
+	if err != nil {
+		return models.SelfExclusion{}, err
+	}
+	if current != nil && (current.EndsAt == nil || (finite && !end.After(*current.EndsAt))) {
+		return models.SelfExclusion{}, ErrAlreadyExcluded
+	}
+
+	e := models.SelfExclusion{Period: period, StartsAt: now, CreatedAt: now}
+	var endsAt sql.NullTime
+	if finite {
+		e.EndsAt = &end
+		endsAt = sql.NullTime{Time: end, Valid: true}
+	}
+	res, err := tx.Exec("INSERT INTO self_exclusions (user_id, period, starts_at, ends_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		userID, period, now, endsAt, now)
+	if err != nil {
+		return models.SelfExclusion{}, err
+	}
+	e.ID, _ = res.LastInsertId()
+	return e, tx.Commit()
+}
+
+// History lists every exclusion a user has requested, newest first.
+func History(userID int) ([]models.SelfExclusion, error) {
+	rows, err := database.DB.Query("SELECT "+columns+" FROM self_exclusions WHERE user_id = ? ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.SelfExclusion{}
+	for rows.Next() {
+		e, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, e)
+	}
+	return history, rows.Err()
+}
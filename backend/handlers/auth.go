@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"casino-hub/backend/database"
+	"casino-hub/backend/exclusion"
 	"casino-hub/backend/models"
+	"casino-hub/backend/wallet"
 	"context"
 	"database/sql"
 	"encoding/hex"
@@ -68,8 +70,15 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := database.DB.Exec(
-		"INSERT INTO users (username, email, password, balance, score, level, experience, freeSpins) VALUES (?, ?, ?, 5000, 0, 1, 0, 0)",
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO users (username, email, password, balance, score, level, experience, freeSpins) VALUES (?, ?, ?, 0, 0, 1, 0, 0)",
 		user.Username, user.Email, string(hashed),
 	)
 	if err != nil {
@@ -81,6 +90,26 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 	user.ID = int(id)
 	user.Password = "" 
 
+	// Every currency opens with its own welcome amount.
+	for _, code := range wallet.CurrencyCodes {
+		bonus := wallet.Currencies[code].SignupBonus
+		if bonus <= 0 {
+			continue
+		}
+		t, err := wallet.PostTx(tx, wallet.Entry{UserID: user.ID, Type: wallet.Credit, Amount: bonus, Currency: code, Reason: wallet.ReasonSignup, Contra: wallet.PromotionsAccount})
+		if err != nil {
+			http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if code == wallet.GoldCoins {
+			user.Balance = t.BalanceAfter
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
@@ -140,9 +169,20 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Excluded players can still sign in to reach their profile, history and
+	// support, so the exclusion is reported instead of refusing the login.
+	excluded, err := exclusion.Active(user.ID, time.Now())
+	if err != nil {
+		log.Println("Login exclusion check failed:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"token": tokenString,
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         tokenString,
+		"selfExcluded":  excluded != nil,
+		"selfExclusion": excluded,
 	})
 }
 
@@ -172,11 +212,40 @@ func AuthMiddleWare(next http.Handler) http.Handler {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
+		if !excludedAllowed(r) {
+			excluded, err := exclusion.Active(claims.UserID, time.Now())
+			if err != nil {
+				log.Println("Exclusion check failed:", err)
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return
+			}
+			if excluded != nil {
+				writeError(w, http.StatusForbidden, "self_excluded", "Your account is self-excluded from gaming", excluded)
+				return
+			}
+		}
 		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// excludedAllowed lists what a self-excluded player can still use: their
+// profile, wallet history and redemptions, support and the exclusion itself.
+// Everything else, including any route added later, is blocked.
+func excludedAllowed(r *http.Request) bool {
+	path := r.URL.Path
+	switch {
+	case path == "/api/v1/users/profile",
+		path == "/api/v1/contact",
+		path == "/api/v1/self-exclusion",
+		path == "/api/v1/wallet", strings.HasPrefix(path, "/api/v1/wallet/"):
+		return true
+	case path == "/api/v1/balance", path == "/api/v1/limits":
+		return r.Method == http.MethodGet
+	}
+	return false
+}
+
 func GetUserID(ctx context.Context) (int, bool) {
 	id, ok := ctx.Value(userIDKey).(int)
 	return id, ok
@@ -207,7 +276,7 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 
 	err := database.DB.QueryRow(`
 		SELECT 
-			id, username, email, name, balance, score, level, experience, freeSpins, 
+			id, username, email, name, balance, bonus_balance, score, level, experience, freeSpins, 
 			lastActive, created_at, last_free_coins, last_cash_claim, last_wheel_spin, free_games
 		FROM users WHERE id = ?`, userID).Scan(
 		&user.ID,
@@ -215,6 +284,7 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 		&user.Email,
 		&name,
 		&user.Balance,
+		&user.BonusBalance,
 		&user.Score,
 		&user.Level,
 		&user.Experience,
@@ -253,6 +323,18 @@ func GetProfile(w http.ResponseWriter, r *http.Request) {
 	user.LastCashClaim = lastCashClaim
 	user.LastWheelSpin = lastWheelSpin
 
+	user.Bonuses, err = wallet.Grants(userID, true)
+	if err != nil {
+		log.Println("Error querying bonus grants:", err)
+		user.Bonuses = []models.BonusGrant{}
+	}
+
+	summary, err := wallet.Summary(userID)
+	if err != nil {
+		log.Println("Error querying wallets:", err)
+	}
+	user.Wallets = summary.Currencies
+
 
 	rows, err := database.DB.Query(
 		`SELECT game_name FROM user_favourites WHERE user_id = ?`,
@@ -1,13 +1,15 @@
 package handlers
 
 import (
-	"casino-hub/backend/database"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"casino-hub/backend/wallet"
+	"database/sql"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"net/http"
-	"time"
+	"strconv"
 )
 
 func getCardValue(val int) int{
@@ -28,18 +30,41 @@ func getCardDisplay(val int, suit string) string{
 	case 13:
 		return "K" + suit
 	default:
-		return string(rune('0'+val)) + suit
+		return strconv.Itoa(val) + suit
 	}
 }
 
-func drawCard() models.BaccaratCard {
-	faceValue := rand.Intn(13)+1
-	suit := suits[rand.Intn(len(suits))]
+// Baccarat is dealt from a standard 8-deck shoe with the cut card near the
+// end. Every variant is dealt at baccaratTables tables, each with its own
+// shoe, so coups at different tables do not wait on one shoe's lock.
+const (
+	baccaratDecks       = 8
+	baccaratPenetration = 0.95
+	baccaratTables      = 4
+)
+
+// baccaratTableID is the shoe of table n (1 to baccaratTables) of a variant.
+func baccaratTableID(variant string, n int) string {
+	return fmt.Sprintf("baccarat-%s-%d", variant, n)
+}
+
+// baccaratSeat returns the table a player is dealt at: the one they asked
+// for, or else one picked by account, so a player keeps to the same shoe and
+// scoreboard.
+func baccaratSeat(userID, requested int) (int, bool) {
+	if requested == 0 {
+		return userID%baccaratTables + 1, true
+	}
+	return requested, requested >= 1 && requested <= baccaratTables
+}
+
+func drawCard(s *shoe.Shoe) models.BaccaratCard {
+	c := s.Draw()
 	return models.BaccaratCard {
-		Suit: suit,
-		Value: getCardValue(faceValue),
-		Display: getCardDisplay(faceValue, suit),
-		FaceValue: faceValue,
+		Suit: c.Suit,
+		Value: getCardValue(c.Rank),
+		Display: getCardDisplay(c.Rank, c.Suit),
+		FaceValue: c.Rank,
 	}
 }
 
@@ -51,43 +76,21 @@ func calculateTotal(cards []models.BaccaratCard) int{
 	return sum % 10
 }
 
-func PlayBaccarat(w http.ResponseWriter, r *http.Request) {
-	rand.Seed(time.Now().UnixNano())
-
-	var bet models.Bet
-	if err := json.NewDecoder(r.Body).Decode(&bet); err != nil {
-		http.Error(w, "Invalid bet", http.StatusBadRequest)
-		return
+// dealBaccarat deals a coup from the shoe, drawing third cards by the
+// tableau.
+func dealBaccarat(s *shoe.Shoe) (playerCards, bankerCards []models.BaccaratCard, playerTotal, bankerTotal int) {
+	// Cards go out one at a time, player first.
+	for range 2 {
+		playerCards = append(playerCards, drawCard(s))
+		bankerCards = append(bankerCards, drawCard(s))
 	}
 
-	userID, ok := GetUserID(r.Context())
-	if !ok || userID <= 0 {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	var userBalance int
-	if err := database.DB.QueryRow("SELECT balance FROM users WHERE id = ?", userID).Scan(&userBalance); err != nil {
-		http.Error(w, "Could not fetch user balance", http.StatusInternalServerError)
-		return
-	}
-
-	if userBalance < bet.Amount {
-		http.Error(w, "Insufficient balance", http.StatusBadRequest)
-		return
-	}
-
-	userBalance -= bet.Amount
-
-	playerCards := []models.BaccaratCard{drawCard(), drawCard()}
-	bankerCards := []models.BaccaratCard{drawCard(), drawCard()}
-
-	playerTotal := calculateTotal(playerCards)
-	bankerTotal := calculateTotal(bankerCards)
+	playerTotal = calculateTotal(playerCards)
+	bankerTotal = calculateTotal(bankerCards)
 
 	if playerTotal < 8 && bankerTotal < 8 {
 		if playerTotal <= 5 {
-			third := drawCard()
+			third := drawCard(s)
 			playerCards = append(playerCards, third)
 			playerTotal = calculateTotal(playerCards)
 
@@ -97,65 +100,124 @@ func PlayBaccarat(w http.ResponseWriter, r *http.Request) {
 				(bankerTotal == 4 && ptc >= 2 && ptc <= 7) ||
 				(bankerTotal == 5 && ptc >= 4 && ptc <= 7) ||
 				(bankerTotal == 6 && (ptc == 6 || ptc == 7)) {
-				thirdB := drawCard()
+				thirdB := drawCard(s)
 				bankerCards = append(bankerCards, thirdB)
 				bankerTotal = calculateTotal(bankerCards)
 			}
 		} else if bankerTotal <= 5 {
-			thirdB := drawCard()
+			thirdB := drawCard(s)
 			bankerCards = append(bankerCards, thirdB)
 			bankerTotal = calculateTotal(bankerCards)
 		}
 	}
+	return
+}
 
-	var winner models.BetType
-	if playerTotal > bankerTotal {
-		winner = models.Player
-	} else if bankerTotal > playerTotal {
-		winner = models.Banker
+// dealCoup deals a coup and decides who won it.
+func dealCoup(s *shoe.Shoe) baccaratCoup {
+	var c baccaratCoup
+	c.playerCards, c.bankerCards, c.playerTotal, c.bankerTotal = dealBaccarat(s)
+	if c.playerTotal > c.bankerTotal {
+		c.winner = models.Player
+	} else if c.bankerTotal > c.playerTotal {
+		c.winner = models.Banker
 	} else {
-		winner = models.Tie
+		c.winner = models.Tie
+	}
+	return c
+}
+
+func PlayBaccarat(w http.ResponseWriter, r *http.Request) {
+	var bet models.Bet
+	if err := json.NewDecoder(r.Body).Decode(&bet); err != nil {
+		http.Error(w, "Invalid bet", http.StatusBadRequest)
+		return
 	}
 
-	winAmount := 0
-	message := ""
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	if bet.Type == winner {
-		switch winner {
-		case models.Player:
-			winAmount = bet.Amount * 2
-		case models.Banker:
-			winAmount = int(float64(bet.Amount) * 1.95) 
-		case models.Tie:
-			winAmount = bet.Amount * 9
+	wagers, stake, err := baccaratWagers(bet)
+	if err != nil {
+		http.Error(w, "Invalid bet: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	table := bet.Table
+	if table == "" {
+		table = baccaratStandard
+	}
+	if table != baccaratStandard && table != baccaratNoCommission {
+		http.Error(w, "Unknown table", http.StatusBadRequest)
+		return
+	}
+	tableNumber, ok := baccaratSeat(userID, bet.TableNumber)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Table number must be 1-%d", baccaratTables), http.StatusBadRequest)
+		return
+	}
+	tableID := baccaratTableID(table, tableNumber)
+
+	var coup baccaratCoup
+	var results []models.WagerResult
+	var winAmount money.Amount
+	roundID := wallet.NewRoundID()
+	settlement, ok := settleBet(w, wallet.Bet{UserID: userID, Game: "Baccarat", Currency: bet.Currency, RoundID: roundID, Stake: stake}, func(tx *sql.Tx) (money.Amount, error) {
+		cards, err := shoe.LoadTx(tx, tableID, baccaratDecks, baccaratPenetration)
+		if err != nil {
+			return 0, err
+		}
+		cards.NewRound()
+		coup = dealCoup(cards)
+		if err := cards.SaveTx(tx); err != nil {
+			return 0, err
+		}
+		if err := recordBaccaratRound(tx, roundID, userID, tableID, table, cards.Number, coup); err != nil {
+			return 0, err
 		}
 
-		userBalance += winAmount
-		message = fmt.Sprintf("🎉 %s wins! You won %d", winner, winAmount-bet.Amount)
-	} else {
-		message = fmt.Sprintf("%s wins. Better luck next round!", winner)
+		for _, wager := range wagers {
+			res := settleWager(wager, coup, table)
+			results = append(results, res)
+			winAmount += res.Payout
+		}
+		return winAmount, nil
+	})
+	if !ok {
+		return
 	}
+	userBalance := settlement.Balance
 
-	if err := UpdateUserBalanceAbsolute(userID, userBalance); err != nil {
-		http.Error(w, "Could not update balance", http.StatusInternalServerError)
-		return
+	message := ""
+	switch net := winAmount - stake; {
+	case net > 0:
+		message = fmt.Sprintf("🎉 %s wins! You won %s", coup.winner, net)
+	case net == 0:
+		message = fmt.Sprintf("%s wins. Your stake is returned.", coup.winner)
+	default:
+		message = fmt.Sprintf("%s wins. Better luck next round!", coup.winner)
 	}
 
 	result := models.GameResult{
-		PlayerCards: playerCards,
-		BankerCards: bankerCards,
-		PlayerTotal: playerTotal,
-		BankerTotal: bankerTotal,
-		Winner:      winner,
-		WinAmount:   winAmount - bet.Amount, 
-		NewBalance:  userBalance,
-		Message:     message,
+		PlayerCards:     coup.playerCards,
+		BankerCards:     coup.bankerCards,
+		PlayerTotal:     coup.playerTotal,
+		BankerTotal:     coup.bankerTotal,
+		Winner:          coup.winner,
+		Table:           table,
+		TableNumber:     tableNumber,
+		Wagers:          results,
+		WinAmount:       winAmount - stake,
+		NewBalance:      userBalance,
+		Currency:        settlement.Currency,
+		RealityCheckDue: settlement.RealityCheckDue,
+		Message:         message,
 	}
 
 	if err := RecordGamePlay(userID, "Baccarat"); err != nil {
 		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"errors"
+)
+
+// Baccarat table variants. On a no-commission table a banker win pays even
+// money, except a win on 6, which pays 1:2.
+const (
+	baccaratStandard     = "standard"
+	baccaratNoCommission = "no-commission"
+)
+
+var (
+	errUnknownWager   = errors.New("unknown wager type")
+	errDuplicateWager = errors.New("each wager type can only be placed once")
+	errInvalidWager   = errors.New("wager amounts must be positive")
+	errEmptyBetSlip   = errors.New("the bet slip is empty")
+)
+
+var baccaratWagerTypes = map[models.BetType]bool{
+	models.Player:       true,
+	models.Banker:       true,
+	models.Tie:          true,
+	models.PlayerPair:   true,
+	models.BankerPair:   true,
+	models.EitherPair:   true,
+	models.PerfectPair:  true,
+	models.PlayerDragon: true,
+	models.BankerDragon: true,
+}
+
+// dragonBonus pays a non-natural win by its margin over the other hand.
+var dragonBonus = map[int]int64{9: 30, 8: 10, 7: 6, 6: 4, 5: 2, 4: 1}
+
+// baccaratWagers returns the wagers on a bet slip, checking that each is
+// valid, and their total stake.
+func baccaratWagers(bet models.Bet) ([]models.Wager, money.Amount, error) {
+	wagers := bet.Wagers
+	if len(wagers) == 0 && bet.Type != "" {
+		wagers = []models.Wager{{Type: bet.Type, Amount: bet.Amount}}
+	}
+	if len(wagers) == 0 {
+		return nil, 0, errEmptyBetSlip
+	}
+
+	var total money.Amount
+	seen := map[models.BetType]bool{}
+	for _, w := range wagers {
+		if !baccaratWagerTypes[w.Type] {
+			return nil, 0, errUnknownWager
+		}
+		if seen[w.Type] {
+			return nil, 0, errDuplicateWager
+		}
+		if w.Amount <= 0 {
+			return nil, 0, errInvalidWager
+		}
+		seen[w.Type] = true
+		total += w.Amount
+	}
+	return wagers, total, nil
+}
+
+// baccaratCoup is a dealt round, as the wagers see it.
+type baccaratCoup struct {
+	playerCards, bankerCards []models.BaccaratCard
+	playerTotal, bankerTotal int
+	winner                   models.BetType
+}
+
+func (c baccaratCoup) natural() bool {
+	return (len(c.playerCards) == 2 && c.playerTotal >= 8) || (len(c.bankerCards) == 2 && c.bankerTotal >= 8)
+}
+
+func isPair(cards []models.BaccaratCard) bool {
+	return cards[0].FaceValue == cards[1].FaceValue
+}
+
+func isPerfectPair(cards []models.BaccaratCard) bool {
+	return isPair(cards) && cards[0].Suit == cards[1].Suit
+}
+
+// settleWager works out a single wager. The payout includes the stake.
+func settleWager(w models.Wager, c baccaratCoup, table string) models.WagerResult {
+	res := models.WagerResult{Type: w.Type, Amount: w.Amount, Outcome: models.WagerLose}
+	win := func(num, den int64) {
+		res.Outcome = models.WagerWin
+		res.Payout = w.Amount + w.Amount.MulFrac(num, den)
+	}
+	push := func() {
+		res.Outcome = models.WagerPush
+		res.Payout = w.Amount
+	}
+
+	switch w.Type {
+	case models.Player:
+		switch c.winner {
+		case models.Player:
+			win(1, 1)
+		case models.Tie:
+			push()
+		}
+	case models.Banker:
+		switch {
+		case c.winner == models.Tie:
+			push()
+		case c.winner != models.Banker:
+		case table != baccaratNoCommission:
+			// Even money less 5% commission.
+			win(19, 20)
+		case c.bankerTotal == 6:
+			win(1, 2)
+		default:
+			win(1, 1)
+		}
+	case models.Tie:
+		if c.winner == models.Tie {
+			win(8, 1)
+		}
+	case models.PlayerPair:
+		if isPair(c.playerCards) {
+			win(11, 1)
+		}
+	case models.BankerPair:
+		if isPair(c.bankerCards) {
+			win(11, 1)
+		}
+	case models.EitherPair:
+		if isPair(c.playerCards) || isPair(c.bankerCards) {
+			win(5, 1)
+		}
+	case models.PerfectPair:
+		player, banker := isPerfectPair(c.playerCards), isPerfectPair(c.bankerCards)
+		if player && banker {
+			win(200, 1)
+		} else if player || banker {
+			win(25, 1)
+		}
+	case models.PlayerDragon, models.BankerDragon:
+		side, margin := models.Player, c.playerTotal-c.bankerTotal
+		if w.Type == models.BankerDragon {
+			side, margin = models.Banker, -margin
+		}
+		switch {
+		case c.natural() && c.winner == models.Tie:
+			push()
+		case c.winner != side:
+		case c.natural():
+			win(1, 1)
+		case dragonBonus[margin] > 0:
+			win(dragonBonus[margin], 1)
+		}
+	}
+	return res
+}
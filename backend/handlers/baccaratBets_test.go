@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"testing"
+)
+
+func card(rank int, suit string) shoe.Card {
+	return shoe.Card{Rank: rank, Suit: suit}
+}
+
+// stackedCoup deals a coup from a shoe holding exactly cards, in dealing
+// order: player, banker, player, banker, then any third cards.
+func stackedCoup(t *testing.T, cards ...shoe.Card) baccaratCoup {
+	t.Helper()
+	s := &shoe.Shoe{Table: baccaratTableID(baccaratStandard, 1), Decks: baccaratDecks, Cards: cards}
+	c := dealCoup(s)
+	if s.Position != len(cards) {
+		t.Fatalf("coup dealt %d of the %d stacked cards", s.Position, len(cards))
+	}
+	return c
+}
+
+// Known shoe sequences.
+var (
+	// Player K 6 (6) against banker 3 4 (7); both stand.
+	bankerSeven = []shoe.Card{card(13, "♠"), card(3, "♠"), card(6, "♠"), card(4, "♠")}
+	// Player 10 5 (5) draws a king; banker 2 4 (6) stands on a third card
+	// of 0.
+	bankerSix = []shoe.Card{card(10, "♠"), card(2, "♠"), card(5, "♠"), card(4, "♠"), card(13, "♥")}
+	// Player 3 4 against banker 2 5, a tie on 7.
+	tieSeven = []shoe.Card{card(3, "♠"), card(2, "♠"), card(4, "♠"), card(5, "♠")}
+	// Player 4♠ 4♥, a natural 8 and a pair, against banker 2 3 (5).
+	playerPairNatural = []shoe.Card{card(4, "♠"), card(2, "♠"), card(4, "♥"), card(3, "♠")}
+	// Player 4♠ 4♠ (8) against banker 2 3 (5).
+	perfectPlayer = []shoe.Card{card(4, "♠"), card(2, "♠"), card(4, "♠"), card(3, "♠")}
+	// Player 4♠ 4♠ (8) against banker 3♥ 3♥ (6).
+	perfectBoth = []shoe.Card{card(4, "♠"), card(3, "♥"), card(4, "♠"), card(3, "♥")}
+	// Natural 8 against natural 8.
+	naturalTie = []shoe.Card{card(4, "♠"), card(3, "♠"), card(4, "♥"), card(5, "♠")}
+	// Player 2 3 draws a 4 (9); banker K J (0) draws a queen (0).
+	playerNine = []shoe.Card{card(2, "♠"), card(13, "♠"), card(3, "♠"), card(11, "♠"), card(4, "♠"), card(12, "♠")}
+	// Player 2 3 draws a 2 (7); banker K J (0) draws a 4 (4).
+	playerByThree = []shoe.Card{card(2, "♠"), card(13, "♠"), card(3, "♠"), card(11, "♠"), card(2, "♥"), card(4, "♠")}
+)
+
+func TestDealCoup(t *testing.T) {
+	tests := []struct {
+		name                     string
+		cards                    []shoe.Card
+		playerTotal, bankerTotal int
+		winner                   models.BetType
+		natural                  bool
+	}{
+		{"both stand", bankerSeven, 6, 7, models.Banker, false},
+		{"banker stands on 6 against a third card of 0", bankerSix, 5, 6, models.Banker, false},
+		{"tie", tieSeven, 7, 7, models.Tie, false},
+		{"natural", playerPairNatural, 8, 5, models.Player, true},
+		{"natural tie", naturalTie, 8, 8, models.Tie, true},
+		{"both draw", playerNine, 9, 0, models.Player, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stackedCoup(t, tt.cards...)
+			if c.playerTotal != tt.playerTotal || c.bankerTotal != tt.bankerTotal || c.winner != tt.winner || c.natural() != tt.natural {
+				t.Errorf("got %d-%d %s natural=%v, want %d-%d %s natural=%v",
+					c.playerTotal, c.bankerTotal, c.winner, c.natural(), tt.playerTotal, tt.bankerTotal, tt.winner, tt.natural)
+			}
+		})
+	}
+}
+
+func TestSettleWager(t *testing.T) {
+	ten := money.Coins(10)
+	tests := []struct {
+		name    string
+		wager   models.BetType
+		amount  money.Amount
+		cards   []shoe.Card
+		table   string
+		outcome string
+		payout  money.Amount
+	}{
+		{"banker pays 19:20", models.Banker, ten, bankerSeven, baccaratStandard, models.WagerWin, money.Coins(19) + 50},
+		{"banker commission rounds half to even", models.Banker, 1010, bankerSeven, baccaratStandard, models.WagerWin, 1970},
+		{"banker on 6 pays 19:20 with commission", models.Banker, ten, bankerSix, baccaratStandard, models.WagerWin, money.Coins(19) + 50},
+		{"no-commission banker pays even money", models.Banker, ten, bankerSeven, baccaratNoCommission, models.WagerWin, money.Coins(20)},
+		{"no-commission banker on 6 pays 1:2", models.Banker, ten, bankerSix, baccaratNoCommission, models.WagerWin, money.Coins(15)},
+		{"banker loses", models.Banker, ten, playerPairNatural, baccaratStandard, models.WagerLose, 0},
+		{"banker pushes on a tie", models.Banker, ten, tieSeven, baccaratStandard, models.WagerPush, ten},
+		{"player pays even money", models.Player, ten, playerPairNatural, baccaratStandard, models.WagerWin, money.Coins(20)},
+		{"player pushes on a tie", models.Player, ten, tieSeven, baccaratStandard, models.WagerPush, ten},
+		{"player loses", models.Player, ten, bankerSix, baccaratNoCommission, models.WagerLose, 0},
+		{"tie pays 8:1", models.Tie, ten, tieSeven, baccaratStandard, models.WagerWin, money.Coins(90)},
+		{"tie loses", models.Tie, ten, bankerSeven, baccaratStandard, models.WagerLose, 0},
+		{"player pair pays 11:1", models.PlayerPair, ten, playerPairNatural, baccaratStandard, models.WagerWin, money.Coins(120)},
+		{"banker pair loses", models.BankerPair, ten, playerPairNatural, baccaratStandard, models.WagerLose, 0},
+		{"banker pair pays 11:1", models.BankerPair, ten, perfectBoth, baccaratStandard, models.WagerWin, money.Coins(120)},
+		{"either pair pays 5:1", models.EitherPair, ten, playerPairNatural, baccaratStandard, models.WagerWin, money.Coins(60)},
+		{"either pair loses", models.EitherPair, ten, tieSeven, baccaratStandard, models.WagerLose, 0},
+		{"perfect pair needs matching suits", models.PerfectPair, ten, playerPairNatural, baccaratStandard, models.WagerLose, 0},
+		{"perfect pair pays 25:1", models.PerfectPair, ten, perfectPlayer, baccaratStandard, models.WagerWin, money.Coins(260)},
+		{"perfect pair on both hands pays 200:1", models.PerfectPair, ten, perfectBoth, baccaratStandard, models.WagerWin, money.Coins(2010)},
+		{"dragon natural win pays even money", models.PlayerDragon, ten, playerPairNatural, baccaratStandard, models.WagerWin, money.Coins(20)},
+		{"dragon pushes on a natural tie", models.PlayerDragon, ten, naturalTie, baccaratStandard, models.WagerPush, ten},
+		{"dragon loses on any other tie", models.BankerDragon, ten, tieSeven, baccaratStandard, models.WagerLose, 0},
+		{"dragon win by 9 pays 30:1", models.PlayerDragon, ten, playerNine, baccaratStandard, models.WagerWin, money.Coins(310)},
+		{"dragon win by 3 loses", models.PlayerDragon, ten, playerByThree, baccaratStandard, models.WagerLose, 0},
+		{"banker dragon win by 1 loses", models.BankerDragon, ten, bankerSeven, baccaratStandard, models.WagerLose, 0},
+		{"dragon on the losing side loses", models.BankerDragon, ten, playerNine, baccaratStandard, models.WagerLose, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stackedCoup(t, tt.cards...)
+			res := settleWager(models.Wager{Type: tt.wager, Amount: tt.amount}, c, tt.table)
+			if res.Outcome != tt.outcome || res.Payout != tt.payout {
+				t.Errorf("got %s paying %s, want %s paying %s", res.Outcome, res.Payout, tt.outcome, tt.payout)
+			}
+		})
+	}
+}
+
+func TestBaccaratSeat(t *testing.T) {
+	tests := []struct {
+		userID, requested int
+		want              int
+		ok                bool
+	}{
+		{7, 2, 2, true},
+		{7, baccaratTables, baccaratTables, true},
+		{7, baccaratTables + 1, 0, false},
+		{7, -1, 0, false},
+		{4, 0, 1, true},
+		{5, 0, 2, true},
+		{7, 0, 4, true},
+	}
+	for _, tt := range tests {
+		got, ok := baccaratSeat(tt.userID, tt.requested)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("baccaratSeat(%d, %d) = %d, %v, want %d, %v", tt.userID, tt.requested, got, ok, tt.want, tt.ok)
+		}
+	}
+}
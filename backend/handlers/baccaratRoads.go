@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// roadRows is the height of every scoreboard grid.
+const roadRows = 6
+
+// baccaratRecord is a finished coup as the scoreboard sees it.
+type baccaratRecord struct {
+	Winner     models.BetType
+	PlayerPair bool
+	BankerPair bool
+}
+
+func recordBaccaratRound(tx *sql.Tx, roundID string, userID int, tableID, variant string, shoeNumber int, c baccaratCoup) error {
+	_, err := tx.Exec(`
+		INSERT INTO baccarat_rounds (round_id, user_id, table_id, variant, shoe_number, winner, player_total, banker_total, player_pair, banker_pair, is_natural, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		roundID, userID, tableID, variant, shoeNumber, string(c.winner), c.playerTotal, c.bankerTotal,
+		isPair(c.playerCards), isPair(c.bankerCards), c.natural(), time.Now())
+	return err
+}
+
+// GetBaccaratRoads godoc
+// @Summary Get the baccarat scoreboard
+// @Description Returns the bead plate, big road, big eye boy, small road and cockroach pig of a shoe at a table, the current one by default. The table defaults to the one the player is seated at.
+// @Tags baccarat
+// @Produce json
+// @Param table query string false "standard (default) or no-commission"
+// @Param tableNumber query int false "Table number"
+// @Param shoe query int false "Shoe number"
+// @Success 200 {object} models.BaccaratRoads
+// @Failure 400 {string} string "Invalid table or shoe"
+// @Router /api/v1/baccarat/roads [get]
+func GetBaccaratRoads(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	table := r.URL.Query().Get("table")
+	if table == "" {
+		table = baccaratStandard
+	}
+	if table != baccaratStandard && table != baccaratNoCommission {
+		http.Error(w, "Unknown table", http.StatusBadRequest)
+		return
+	}
+	var requested int
+	if v := r.URL.Query().Get("tableNumber"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid table number", http.StatusBadRequest)
+			return
+		}
+		requested = n
+	}
+	tableNumber, ok := baccaratSeat(userID, requested)
+	if !ok {
+		http.Error(w, "Invalid table number", http.StatusBadRequest)
+		return
+	}
+	tableID := baccaratTableID(table, tableNumber)
+
+	var shoeNumber int
+	if v := r.URL.Query().Get("shoe"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid shoe", http.StatusBadRequest)
+			return
+		}
+		shoeNumber = n
+	} else {
+		err := database.DB.QueryRow("SELECT shoe_number FROM shoes WHERE table_id = ?", tableID).Scan(&shoeNumber)
+		if err != nil && err != sql.ErrNoRows {
+			log.Println("GetBaccaratRoads error:", err)
+			http.Error(w, "Failed to fetch roads", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT winner, player_pair, banker_pair
+		FROM baccarat_rounds
+		WHERE table_id = ? AND shoe_number = ?
+		ORDER BY id`, tableID, shoeNumber)
+	if err != nil {
+		log.Println("GetBaccaratRoads error:", err)
+		http.Error(w, "Failed to fetch roads", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var records []baccaratRecord
+	for rows.Next() {
+		var rec baccaratRecord
+		if err := rows.Scan(&rec.Winner, &rec.PlayerPair, &rec.BankerPair); err != nil {
+			log.Println("GetBaccaratRoads error:", err)
+			http.Error(w, "Failed to fetch roads", http.StatusInternalServerError)
+			return
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("GetBaccaratRoads error:", err)
+		http.Error(w, "Failed to fetch roads", http.StatusInternalServerError)
+		return
+	}
+
+	roads := buildRoads(records)
+	roads.Table = table
+	roads.TableNumber = tableNumber
+	roads.Shoe = shoeNumber
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(roads)
+}
+
+func roadResult(winner models.BetType) string {
+	switch winner {
+	case models.Player:
+		return "P"
+	case models.Banker:
+		return "B"
+	}
+	return "T"
+}
+
+// buildRoads lays out the five scoreboards for a shoe's coups, oldest
+// first.
+func buildRoads(records []baccaratRecord) models.BaccaratRoads {
+	roads := models.BaccaratRoads{Rounds: len(records), BeadPlate: []models.RoadCell{}}
+
+	// The bead plate shows every coup, top to bottom and left to right.
+	for i, rec := range records {
+		roads.BeadPlate = append(roads.BeadPlate, models.RoadCell{
+			Col:        i / roadRows,
+			Row:        i % roadRows,
+			Result:     roadResult(rec.Winner),
+			PlayerPair: rec.PlayerPair,
+			BankerPair: rec.BankerPair,
+		})
+	}
+
+	// The big road starts a new column whenever the winner changes. Ties
+	// are counted on the mark before them, or on the first mark when the
+	// shoe opens with a tie.
+	var columns [][]models.RoadCell
+	leadingTies := 0
+	for _, rec := range records {
+		if rec.Winner == models.Tie {
+			if len(columns) == 0 {
+				leadingTies++
+				continue
+			}
+			last := columns[len(columns)-1]
+			last[len(last)-1].Ties++
+			continue
+		}
+		cell := models.RoadCell{Result: roadResult(rec.Winner), PlayerPair: rec.PlayerPair, BankerPair: rec.BankerPair}
+		if len(columns) == 0 {
+			cell.Ties, leadingTies = leadingTies, 0
+		}
+		if n := len(columns); n > 0 && columns[n-1][0].Result == cell.Result {
+			columns[n-1] = append(columns[n-1], cell)
+		} else {
+			columns = append(columns, []models.RoadCell{cell})
+		}
+	}
+	roads.BigRoad = layoutRoad(columns)
+
+	roads.BigEyeBoy = layoutRoad(derivedRoad(columns, 1))
+	roads.SmallRoad = layoutRoad(derivedRoad(columns, 2))
+	roads.CockroachPig = layoutRoad(derivedRoad(columns, 3))
+	return roads
+}
+
+// derivedRoad reads the big road the way the big eye boy (offset 1), small
+// road (2) and cockroach pig (3) do. A mark that starts a column compares
+// the lengths of the two columns before it, offset apart; any other mark
+// checks whether the column offset to the left has a mark at the same
+// height. Red means the big road is repeating itself, blue that it is not.
+func derivedRoad(bigRoad [][]models.RoadCell, offset int) [][]models.RoadCell {
+	var columns [][]models.RoadCell
+	for c, column := range bigRoad {
+		for r := range column {
+			var red bool
+			if r == 0 {
+				if c-1-offset < 0 {
+					continue
+				}
+				red = len(bigRoad[c-1]) == len(bigRoad[c-1-offset])
+			} else {
+				if c-offset < 0 {
+					continue
+				}
+				// Blue only when the compared column ended right above.
+				red = len(bigRoad[c-offset]) != r
+			}
+
+			cell := models.RoadCell{Result: "blue"}
+			if red {
+				cell.Result = "red"
+			}
+			if n := len(columns); n > 0 && columns[n-1][0].Result == cell.Result {
+				columns[n-1] = append(columns[n-1], cell)
+			} else {
+				columns = append(columns, []models.RoadCell{cell})
+			}
+		}
+	}
+	return columns
+}
+
+// layoutRoad places logical columns on a grid roadRows high. A streak that
+// reaches the bottom, or runs into an earlier mark, turns right and carries
+// on along that row (the dragon tail).
+func layoutRoad(columns [][]models.RoadCell) []models.RoadCell {
+	cells := []models.RoadCell{}
+	taken := map[[2]int]bool{}
+	start := 0
+	for i, column := range columns {
+		if i > 0 {
+			start++
+		}
+		for taken[[2]int{start, 0}] {
+			start++
+		}
+		col, row := start, 0
+		turned := false
+		for j, cell := range column {
+			if j > 0 {
+				if !turned && row+1 < roadRows && !taken[[2]int{col, row + 1}] {
+					row++
+				} else {
+					turned = true
+					col++
+				}
+			}
+			taken[[2]int{col, row}] = true
+			cell.Col, cell.Row = col, row
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
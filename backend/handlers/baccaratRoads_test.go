@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// coups turns a string such as "BBPT" into a shoe's records.
+func coups(s string) []baccaratRecord {
+	winners := map[rune]models.BetType{'P': models.Player, 'B': models.Banker, 'T': models.Tie}
+	var records []baccaratRecord
+	for _, r := range s {
+		records = append(records, baccaratRecord{Winner: winners[r]})
+	}
+	return records
+}
+
+func at(col, row int, result string) models.RoadCell {
+	return models.RoadCell{Col: col, Row: row, Result: result}
+}
+
+func withTies(c models.RoadCell, ties int) models.RoadCell {
+	c.Ties = ties
+	return c
+}
+
+func TestBeadPlate(t *testing.T) {
+	records := coups("BPTBBPB")
+	records[1].PlayerPair = true
+	records[6].BankerPair = true
+
+	roads := buildRoads(records)
+	if roads.Rounds != 7 {
+		t.Errorf("Rounds = %d, want 7", roads.Rounds)
+	}
+	want := []models.RoadCell{
+		at(0, 0, "B"),
+		{Col: 0, Row: 1, Result: "P", PlayerPair: true},
+		at(0, 2, "T"),
+		at(0, 3, "B"),
+		at(0, 4, "B"),
+		at(0, 5, "P"),
+		{Col: 1, Row: 0, Result: "B", BankerPair: true},
+	}
+	if !reflect.DeepEqual(roads.BeadPlate, want) {
+		t.Errorf("bead plate = %+v, want %+v", roads.BeadPlate, want)
+	}
+}
+
+func TestBigRoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		coups string
+		want  []models.RoadCell
+	}{
+		{"empty shoe", "", []models.RoadCell{}},
+		{"only ties", "TT", []models.RoadCell{}},
+		{"new column on every change", "BBPB", []models.RoadCell{at(0, 0, "B"), at(0, 1, "B"), at(1, 0, "P"), at(2, 0, "B")}},
+		{"leading ties go on the first mark", "TTBP", []models.RoadCell{withTies(at(0, 0, "B"), 2), at(1, 0, "P")}},
+		{"ties go on the mark before them", "BTTBP", []models.RoadCell{withTies(at(0, 0, "B"), 2), at(0, 1, "B"), at(1, 0, "P")}},
+		{"a tie does not break a streak", "BTB", []models.RoadCell{withTies(at(0, 0, "B"), 1), at(0, 1, "B")}},
+		{"dragon tail turns right at the bottom", "BBBBBBBB", []models.RoadCell{
+			at(0, 0, "B"), at(0, 1, "B"), at(0, 2, "B"), at(0, 3, "B"), at(0, 4, "B"), at(0, 5, "B"),
+			at(1, 5, "B"), at(2, 5, "B"),
+		}},
+		{"dragon tail turns right above an earlier tail", "BBBBBBBPPPPPPPB", []models.RoadCell{
+			at(0, 0, "B"), at(0, 1, "B"), at(0, 2, "B"), at(0, 3, "B"), at(0, 4, "B"), at(0, 5, "B"), at(1, 5, "B"),
+			at(1, 0, "P"), at(1, 1, "P"), at(1, 2, "P"), at(1, 3, "P"), at(1, 4, "P"), at(2, 4, "P"), at(3, 4, "P"),
+			at(2, 0, "B"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildRoads(coups(tt.coups)).BigRoad; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("big road = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDerivedRoads(t *testing.T) {
+	none := []models.RoadCell{}
+	tests := []struct {
+		name                        string
+		coups                       string
+		bigEyeBoy, small, cockroach []models.RoadCell
+	}{
+		{"too short for any", "BBP", none, none, none},
+		{"big eye boy starts on the second mark of the second column", "BPP",
+			[]models.RoadCell{at(0, 0, "blue")}, none, none},
+		{"big eye boy starts on the third column without one", "BPB",
+			[]models.RoadCell{at(0, 0, "red")}, none, none},
+		{"small road starts on the second mark of the third column", "BPBB",
+			[]models.RoadCell{at(0, 0, "red"), at(1, 0, "blue")}, []models.RoadCell{at(0, 0, "blue")}, none},
+		{"small road starts on the fourth column without one", "BPBP",
+			[]models.RoadCell{at(0, 0, "red"), at(0, 1, "red")}, []models.RoadCell{at(0, 0, "red")}, none},
+		{"cockroach starts on the second mark of the fourth column", "BPBPP",
+			[]models.RoadCell{at(0, 0, "red"), at(0, 1, "red"), at(1, 0, "blue")},
+			[]models.RoadCell{at(0, 0, "red"), at(1, 0, "blue")},
+			[]models.RoadCell{at(0, 0, "blue")}},
+		{"cockroach starts on the fifth column without one", "BPBPB",
+			[]models.RoadCell{at(0, 0, "red"), at(0, 1, "red"), at(0, 2, "red")},
+			[]models.RoadCell{at(0, 0, "red"), at(0, 1, "red")},
+			[]models.RoadCell{at(0, 0, "red")}},
+		{"mixed shoe", "BBPBBBPP",
+			[]models.RoadCell{at(0, 0, "blue"), at(0, 1, "blue"), at(1, 0, "red"), at(2, 0, "blue"), at(3, 0, "red")},
+			[]models.RoadCell{at(0, 0, "red"), at(1, 0, "blue"), at(1, 1, "blue"), at(1, 2, "blue")},
+			[]models.RoadCell{at(0, 0, "red")}},
+		{"ties are ignored", "TBTBTPTBTBTBTPTP",
+			[]models.RoadCell{at(0, 0, "blue"), at(0, 1, "blue"), at(1, 0, "red"), at(2, 0, "blue"), at(3, 0, "red")},
+			[]models.RoadCell{at(0, 0, "red"), at(1, 0, "blue"), at(1, 1, "blue"), at(1, 2, "blue")},
+			[]models.RoadCell{at(0, 0, "red")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roads := buildRoads(coups(tt.coups))
+			for _, road := range []struct {
+				name      string
+				got, want []models.RoadCell
+			}{
+				{"big eye boy", roads.BigEyeBoy, tt.bigEyeBoy},
+				{"small road", roads.SmallRoad, tt.small},
+				{"cockroach pig", roads.CockroachPig, tt.cockroach},
+			} {
+				if !reflect.DeepEqual(road.got, road.want) {
+					t.Errorf("%s = %s, want %s", road.name, results(road.got), results(road.want))
+				}
+			}
+		})
+	}
+}
+
+// results prints a road as col,row:result cells.
+func results(cells []models.RoadCell) string {
+	var parts []string
+	for _, c := range cells {
+		parts = append(parts, fmt.Sprintf("%d,%d:%s", c.Col, c.Row, c.Result))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
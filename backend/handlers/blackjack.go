@@ -3,11 +3,16 @@ package handlers
 import (
 	"casino-hub/backend/database"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"casino-hub/backend/wallet"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"net/http"
-	"time"
+	"strings"
 )
 
 var suits = []string{"♠", "♥", "♦", "♣"}
@@ -19,25 +24,33 @@ var values = []struct {
 	{"8", 8}, {"9", 9}, {"10", 10}, {"J", 10}, {"Q", 10}, {"K", 10},
 }
 
-func CreateDeck() []models.Card {
-	deck := []models.Card{}
-	for _, suit := range suits {
-		for _, val := range values {
-			deck = append(deck, models.Card{
-				Suit:     suit,
-				Value:    val.Value,
-				NumValue: val.NumValue,
-			})
+// blackjackPenetration is how far into the shoe the cut card goes.
+const blackjackPenetration = 0.75
+
+// toBlackjackCard maps a shoe card to its blackjack value.
+func toBlackjackCard(c shoe.Card) models.Card {
+	v := values[c.Rank-1]
+	return models.Card{Suit: c.Suit, Value: v.Value, NumValue: v.NumValue}
+}
+
+// toShoeCard maps a blackjack card back to the shoe card it was dealt as.
+func toShoeCard(c models.Card) shoe.Card {
+	for i, v := range values {
+		if v.Value == c.Value {
+			return shoe.Card{Rank: i + 1, Suit: c.Suit}
 		}
 	}
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(deck), func(i, j int) {
-		deck[i], deck[j] = deck[j], deck[i]
-	})
-	return deck
+	return shoe.Card{Suit: c.Suit}
 }
 
 func CalculateScore(cards []models.Card) int {
+	score, _ := calculateScore(cards)
+	return score
+}
+
+// calculateScore also reports whether the total is soft, that is an ace
+// still counts as 11.
+func calculateScore(cards []models.Card) (int, bool) {
 	score := 0
 	aces := 0
 
@@ -55,7 +68,7 @@ func CalculateScore(cards []models.Card) int {
 		aces--
 	}
 
-	return score
+	return score, aces > 0
 }
 
 func IsBlackjack(cards []models.Card) bool {
@@ -71,86 +84,105 @@ func StartGameHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type Req struct { Bet int `json:"bet"` }
+	type Req struct {
+		Bet      money.Amount `json:"bet"`
+		Currency string       `json:"currency"`
+		Table    string       `json:"table"`
+	}
 	var req Req
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	var userBalance int
-	err := database.DB.QueryRow("SELECT balance FROM users WHERE id = ?", userID).Scan(&userBalance)
-	if err != nil {
-		http.Error(w, "Could not fetch user balance", http.StatusInternalServerError)
+	if req.Bet <= 0 {
+		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
 		return
 	}
-
-	if req.Bet <= 0 || req.Bet > userBalance {
-		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
+	rules, ok := lookupBlackjackTable(req.Table)
+	if !ok {
+		http.Error(w, "Unknown table", http.StatusBadRequest)
 		return
 	}
-
-	// DEDUCT THE BET AT GAME START
-	newBalance := userBalance - req.Bet
-	if err := UpdateUserBalanceAbsolute(userID, newBalance); err != nil {
-		http.Error(w, "Could not update balance", http.StatusInternalServerError)
+	if req.Bet < rules.MinBet || (rules.MaxBet > 0 && req.Bet > rules.MaxBet) {
+		msg := fmt.Sprintf("Bet must be at least %s at this table", rules.MinBet)
+		if rules.MaxBet > 0 {
+			msg = fmt.Sprintf("Bet must be between %s and %s at this table", rules.MinBet, rules.MaxBet)
+		}
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
-	deck := CreateDeck()
-	playerCards := []models.Card{deck[0], deck[2]}
-	dealerCards := []models.Card{deck[1], deck[3]}
-	deck = deck[4:]
-
-	state := models.GameState{
-		PlayerCards: playerCards,
-		DealerCards: dealerCards,
-		Deck:        deck,
-		Coins:       newBalance, // Updated balance after bet deduction
-		Bet:         req.Bet,
-		Message:     "Hit or Stand?",
-		GameOver:    false,
-		WinAmount:   0,
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
+	defer tx.Rollback()
 
-	// Immediate blackjack check
-	if IsBlackjack(playerCards) {
-		if IsBlackjack(dealerCards) {
-			// Push - return the bet
-			state.Message = "Both Blackjack! Push!"
-			state.GameOver = true
-			state.WinAmount = req.Bet // Return the bet
-			state.Coins = newBalance + req.Bet // Add back the bet
-		} else {
-			// Player blackjack wins - 3:2 payout plus original bet
-			blackjackWin := int(float64(req.Bet) * 1.5) // 3:2 payout
-			totalWin := req.Bet + blackjackWin // Original bet + winnings
-			state.WinAmount = totalWin
-			state.Message = "BLACKJACK! You win!"
-			state.GameOver = true
-			state.Coins = newBalance + totalWin
+	// The shoe is locked before the stake, the same order every blackjack
+	// action takes.
+	cards, err := loadBlackjackShoe(tx, rules, "")
+	if err != nil {
+		log.Println("Blackjack shoe error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	cards.NewRound()
+	round := &blackjackRound{
+		ID:     wallet.NewRoundID(),
+		UserID: userID,
+		Rules:  rules,
+		Bet:    req.Bet,
+		shoe:   cards,
+	}
+	// Cards go out one at a time, player first.
+	hand := models.BlackjackHand{Bet: req.Bet, Status: handPlaying}
+	for range 2 {
+		hand.Cards = append(hand.Cards, round.draw())
+		round.DealerCards = append(round.DealerCards, round.draw())
+	}
+	round.Hands = []models.BlackjackHand{hand}
+	round.deal()
+
+	// The bet is deducted at game start; an unfinished hand keeps it reserved
+	// until the player's last move settles it. The round is stored in the
+	// same transaction, so there is never a bet without a hand or the other
+	// way round.
+	settlement, ok := settleBetTx(w, tx, wallet.Bet{UserID: userID, Game: "Blackjack", Currency: req.Currency, RoundID: round.ID, Stake: req.Bet}, func(tx *sql.Tx) (money.Amount, error) {
+		round.Currency = req.Currency
+		if round.Currency == "" {
+			round.Currency = wallet.DefaultCurrency
 		}
-	} else if IsBlackjack(dealerCards) {
-		// Dealer blackjack - player loses (bet already deducted)
-		state.Message = "Dealer Blackjack! You lose."
-		state.GameOver = true
-		state.WinAmount = 0 // No winnings, bet already taken
-		// Coins already reflects the loss
-	}
-
-	// Update balance in DB if there are winnings
-	if state.GameOver && state.WinAmount > 0 {
-		if err := UpdateUserBalanceAbsolute(userID, state.Coins); err != nil {
-			http.Error(w, "Could not update balance", http.StatusInternalServerError)
-			return
+		if err := insertBlackjackRound(tx, round); err != nil {
+			return 0, err
+		}
+		if round.GameOver {
+			return round.WinAmount, nil
 		}
+		return 0, nil
+	})
+	if !ok {
+		return
+	}
+	if err := cards.SaveTx(tx); err != nil {
+		log.Println("Blackjack shoe error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	state := round.state()
+	state.Coins = settlement.Balance
+	state.Currency = settlement.Currency
+	state.RealityCheckDue = settlement.RealityCheckDue
 
 	if state.GameOver {
 		if err := RecordGamePlay(userID, "Blackjack"); err != nil {
 			fmt.Println("RecordGamePlay error:", err)
-			http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-			return
 		}
 	}
 
@@ -159,66 +191,152 @@ func StartGameHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func HitHandler(w http.ResponseWriter, r *http.Request) {
+	playBlackjackAction(w, r, (*blackjackRound).hit)
+}
+
+func StandHandler(w http.ResponseWriter, r *http.Request) {
+	playBlackjackAction(w, r, (*blackjackRound).stand)
+}
+
+// DoubleHandler doubles the stake on the hand in play, which then gets
+// exactly one more card.
+func DoubleHandler(w http.ResponseWriter, r *http.Request) {
+	playBlackjackAction(w, r, (*blackjackRound).double)
+}
+
+// SplitHandler splits a pair into two hands with a stake each, as often as
+// the table allows.
+func SplitHandler(w http.ResponseWriter, r *http.Request) {
+	playBlackjackAction(w, r, (*blackjackRound).split)
+}
+
+// InsuranceHandler takes or declines insurance when the dealer shows an ace.
+// It has to be answered before any other move.
+func InsuranceHandler(w http.ResponseWriter, r *http.Request) {
+	playBlackjackAction(w, r, (*blackjackRound).insure)
+}
+
+// SurrenderHandler gives up the opening hand for half the stake back. It is
+// only offered once the dealer has checked for blackjack.
+func SurrenderHandler(w http.ResponseWriter, r *http.Request) {
+	playBlackjackAction(w, r, (*blackjackRound).surrender)
+}
+
+type blackjackActionRequest struct {
+	RoundID string `json:"roundId"`
+	// Accept answers the insurance offer.
+	Accept bool `json:"accept"`
+}
+
+// illegalMove is returned by a blackjack action the round does not allow.
+type illegalMove string
+
+func (e illegalMove) Error() string {
+	return string(e)
+}
+
+// playBlackjackAction applies a player action to a stored round. The client
+// only names the round; cards, stake and balance all come from the server.
+// An action returns the further stake it needs, which is taken in the same
+// transaction. When the action finishes the round, the payout is credited in
+// the transaction that closes it, so a round cannot be paid twice.
+func playBlackjackAction(w http.ResponseWriter, r *http.Request, action func(*blackjackRound, blackjackActionRequest) (money.Amount, error)) {
 	userID, ok := GetUserID(r.Context())
 	if !ok || userID <= 0 {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	type Req struct {
-		Deck        []models.Card `json:"deck"`
-		PlayerCards []models.Card `json:"playerCards"`
-		Coins       int           `json:"coins"`
-		Bet         int           `json:"bet"`
-		DealerCards []models.Card `json:"dealerCards"`
-	}
-	var req Req
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var req blackjackActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RoundID == "" {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	if len(req.Deck) == 0 {
-		http.Error(w, "No cards left in deck", http.StatusBadRequest)
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
 
-	card := req.Deck[0]
-	req.Deck = req.Deck[1:]
-	req.PlayerCards = append(req.PlayerCards, card)
-
-	score := CalculateScore(req.PlayerCards)
+	round, err := loadBlackjackRound(tx, userID, req.RoundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Round not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("Blackjack round error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if round.GameOver {
+		http.Error(w, "Round is already finished", http.StatusConflict)
+		return
+	}
+	if round.shoe, err = loadBlackjackShoe(tx, round.Rules, round.ID); err != nil {
+		log.Println("Blackjack shoe error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
 
-	state := models.GameState{
-		PlayerCards: req.PlayerCards,
-		DealerCards: req.DealerCards,
-		Deck:        req.Deck,
-		Coins:       req.Coins,
-		Bet:         req.Bet,
-		GameOver:    false,
-		Message:     "Hit or Stand?",
-		WinAmount:   0,
+	stake, err := action(round, req)
+	var illegal illegalMove
+	if errors.As(err, &illegal) {
+		http.Error(w, illegal.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Println("Blackjack action error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
 
-	if score > 21 {
-		state.GameOver = true
-		state.Message = "BUST! You lose."
-		state.WinAmount = 0 
-	} else if score == 21 {
-		state = StandLogic(req.Deck, req.PlayerCards, req.DealerCards, req.Coins, req.Bet)
-		if state.WinAmount > 0 {
-			if err := UpdateUserBalanceAbsolute(userID, state.Coins); err != nil {
-				http.Error(w, "Could not update balance", http.StatusInternalServerError)
-				return
-			}
+	var realityCheckDue bool
+	if stake > 0 {
+		settlement, ok := settleBetTx(w, tx, wallet.Bet{UserID: userID, Game: "Blackjack", Currency: round.Currency, RoundID: round.ID, Stake: stake, StakeChecked: true}, func(tx *sql.Tx) (money.Amount, error) {
+			return 0, nil
+		})
+		if !ok {
+			return
 		}
+		realityCheckDue = settlement.RealityCheckDue
+	}
+	if err := saveBlackjackRound(tx, round); err != nil {
+		log.Println("Blackjack round error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := round.shoe.SaveTx(tx); err != nil {
+		log.Println("Blackjack shoe error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
 
-		if state.GameOver {
-			if err := RecordGamePlay(userID, "Blackjack"); err != nil {
-				fmt.Println("RecordGamePlay error:", err)
-				http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-				return
-			}
+	// A round still in play is paid nothing; the balance is read in tx all
+	// the same, so nothing after the commit can fail.
+	var payout money.Amount
+	if round.GameOver {
+		payout = round.WinAmount
+	}
+	t, err := wallet.PayoutTx(tx, userID, "Blackjack", round.ID, round.Currency, payout)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	coins := t.BalanceAfter
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	state := round.state()
+	state.Coins = coins
+	state.RealityCheckDue = realityCheckDue
+
+	if state.GameOver {
+		if err := RecordGamePlay(userID, "Blackjack"); err != nil {
+			fmt.Println("RecordGamePlay error:", err)
 		}
 	}
 
@@ -226,109 +344,358 @@ func HitHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(state)
 }
 
-func StandHandler(w http.ResponseWriter, r *http.Request) {
-	userID, ok := GetUserID(r.Context())
-	if !ok || userID <= 0 {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// ------------------- Game Logic -------------------
+
+// Hand statuses and results.
+const (
+	handPlaying     = "playing"
+	handStood       = "stood"
+	handBust        = "bust"
+	handSurrendered = "surrendered"
+
+	resultBlackjack = "blackjack"
+	resultWin       = "win"
+	resultPush      = "push"
+	resultLose      = "lose"
+	resultSurrender = "surrender"
+)
+
+// deal sets up a freshly dealt round. With an ace showing the dealer offers
+// insurance before checking for blackjack, unless the bet is too small to
+// take half of; otherwise the check happens straight away.
+func (b *blackjackRound) deal() {
+	if b.DealerCards[0].Value == "A" && b.Bet/2 > 0 {
+		b.InsuranceState = insuranceOffered
+		b.Message = "Dealer shows an Ace. Insurance?"
 		return
 	}
+	b.peek()
+}
 
-	type Req struct {
-		Deck        []models.Card `json:"deck"`
-		PlayerCards []models.Card `json:"playerCards"`
-		DealerCards []models.Card `json:"dealerCards"`
-		Coins       int           `json:"coins"`
-		Bet         int           `json:"bet"`
+// peek is the dealer checking the hole card for blackjack, which ends the
+// round at once. Otherwise a player natural is paid 3:2.
+func (b *blackjackRound) peek() {
+	hand := &b.Hands[0]
+	if IsBlackjack(b.DealerCards) {
+		var insurance money.Amount
+		if b.InsuranceState == insuranceTaken {
+			// Insurance pays 2:1.
+			insurance = b.Insurance.Times(3)
+		}
+		hand.Status = handStood
+		if IsBlackjack(hand.Cards) {
+			// Push - return the bet
+			hand.Result, hand.WinAmount = resultPush, hand.Bet
+			b.finish("Both Blackjack! Push!", insurance)
+		} else {
+			// Dealer blackjack - player loses (bet already deducted)
+			hand.Result = resultLose
+			b.finish("Dealer Blackjack! You lose.", insurance)
+		}
+		return
 	}
-	var req Req
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	if IsBlackjack(hand.Cards) {
+		// Player blackjack wins at the table's payout plus original bet
+		pays := blackjackPayouts[b.Rules.BlackjackPays]
+		hand.Status, hand.Result = handStood, resultBlackjack
+		hand.WinAmount = hand.Bet + hand.Bet.MulFrac(pays[0], pays[1])
+		b.finish("BLACKJACK! You win!", 0)
 		return
 	}
+	b.Message = "Hit or Stand?"
+}
 
-	state := StandLogic(req.Deck, req.PlayerCards, req.DealerCards, req.Coins, req.Bet)
-
-	// Update balance if there are winnings
-	if state.WinAmount > 0 {
-		if err := UpdateUserBalanceAbsolute(userID, state.Coins); err != nil {
-			http.Error(w, "Could not update balance", http.StatusInternalServerError)
-			return
+// actions lists the moves open to the player.
+func (b *blackjackRound) actions() []string {
+	actions := []string{}
+	switch {
+	case b.GameOver:
+	case b.InsuranceState == insuranceOffered:
+		actions = append(actions, "insurance")
+	default:
+		hand := b.Hands[b.ActiveHand]
+		actions = append(actions, "hit", "stand")
+		if b.canDouble(hand) {
+			actions = append(actions, "double")
+		}
+		if b.canSplit(hand) {
+			actions = append(actions, "split")
+		}
+		if b.canSurrender() {
+			actions = append(actions, "surrender")
 		}
 	}
+	return actions
+}
 
-	if err := RecordGamePlay(userID, "Blackjack"); err != nil {
-		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-		return
+func (b *blackjackRound) canDouble(hand models.BlackjackHand) bool {
+	if len(b.Hands) > 1 && !b.Rules.DoubleAfterSplit {
+		return false
 	}
+	return len(hand.Cards) == 2 && !hand.Doubled
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(state)
+// canSplit allows a pair of the same rank to be split, and re-split, up to
+// the table's MaxSplits. Split aces get one card each and are never played
+// again, so they cannot be re-split.
+func (b *blackjackRound) canSplit(hand models.BlackjackHand) bool {
+	return len(hand.Cards) == 2 && hand.Cards[0].Value == hand.Cards[1].Value &&
+		!hand.SplitAces && len(b.Hands) <= b.Rules.MaxSplits
 }
 
-// ------------------- Game Logic -------------------
+// canSurrender allows late surrender as the first move on the opening hand.
+func (b *blackjackRound) canSurrender() bool {
+	return len(b.Hands) == 1 && len(b.Hands[0].Cards) == 2 && !b.Hands[0].Doubled
+}
 
-func StandLogic(deck []models.Card, playerCards []models.Card, dealerCards []models.Card, coins int, bet int) models.GameState {
-	playerScore := CalculateScore(playerCards)
-	dealerScore := CalculateScore(dealerCards)
+// checkMove rejects any move but insurance while insurance is on offer.
+func (b *blackjackRound) checkMove() error {
+	if b.InsuranceState == insuranceOffered {
+		return illegalMove("Take or decline insurance first")
+	}
+	return nil
+}
 
-	// Dealer hits until 17 or higher
-	for dealerScore < 17 && len(deck) > 0 {
-		card := deck[0]
-		deck = deck[1:]
-		dealerCards = append(dealerCards, card)
-		dealerScore = CalculateScore(dealerCards)
+func (b *blackjackRound) hit(req blackjackActionRequest) (money.Amount, error) {
+	if err := b.checkMove(); err != nil {
+		return 0, err
 	}
+	hand := &b.Hands[b.ActiveHand]
+	hand.Cards = append(hand.Cards, b.draw())
 
-	var message string
-	var winAmount int
-	var finalCoins int
+	score := CalculateScore(hand.Cards)
+	if score > 21 {
+		hand.Status = handBust
+		b.next("BUST!")
+	} else if score == 21 {
+		hand.Status = handStood
+		b.next("21!")
+	} else {
+		b.Message = "Hit or Stand?"
+	}
+	return 0, nil
+}
 
-	if dealerScore > 21 {
-		message = "Dealer busts! You win!"
-		winAmount = bet * 2 
-		finalCoins = coins + winAmount
-	} else if playerScore > dealerScore {
-		message = "You win!"
-		winAmount = bet * 2 
-		finalCoins = coins + winAmount
-	} else if playerScore == dealerScore {
-		message = "Push! It's a tie."
-		winAmount = bet 
-		finalCoins = coins + winAmount
+func (b *blackjackRound) stand(req blackjackActionRequest) (money.Amount, error) {
+	if err := b.checkMove(); err != nil {
+		return 0, err
+	}
+	b.Hands[b.ActiveHand].Status = handStood
+	b.next("")
+	return 0, nil
+}
+
+func (b *blackjackRound) double(req blackjackActionRequest) (money.Amount, error) {
+	if err := b.checkMove(); err != nil {
+		return 0, err
+	}
+	hand := &b.Hands[b.ActiveHand]
+	if !b.canDouble(*hand) {
+		if len(b.Hands) > 1 && !b.Rules.DoubleAfterSplit {
+			return 0, illegalMove("This table does not allow doubling after a split")
+		}
+		return 0, illegalMove("You can only double on your first two cards")
+	}
+	stake := hand.Bet
+	hand.Bet = hand.Bet.Times(2)
+	hand.Doubled = true
+	hand.Cards = append(hand.Cards, b.draw())
+	if CalculateScore(hand.Cards) > 21 {
+		hand.Status = handBust
+		b.next("BUST!")
 	} else {
-		message = "Dealer wins!"
-		winAmount = 0 
-		finalCoins = coins
+		hand.Status = handStood
+		b.next("")
 	}
+	return stake, nil
+}
+
+func (b *blackjackRound) split(req blackjackActionRequest) (money.Amount, error) {
+	if err := b.checkMove(); err != nil {
+		return 0, err
+	}
+	hand := &b.Hands[b.ActiveHand]
+	if !b.canSplit(*hand) {
+		return 0, illegalMove("This hand cannot be split")
+	}
+	aces := hand.Cards[0].Value == "A"
+	second := models.BlackjackHand{Cards: []models.Card{hand.Cards[1]}, Bet: hand.Bet, Status: handPlaying, SplitAces: aces}
+	hand.Cards = []models.Card{hand.Cards[0], b.draw()}
+	hand.SplitAces = aces
+	second.Cards = append(second.Cards, b.draw())
+
+	// The new hand is played right after the one it was split from.
+	i := b.ActiveHand + 1
+	b.Hands = append(b.Hands[:i], append([]models.BlackjackHand{second}, b.Hands[i:]...)...)
+	hand = &b.Hands[b.ActiveHand]
+
+	if aces {
+		b.Hands[i-1].Status = handStood
+		b.Hands[i].Status = handStood
+		b.next("Split aces get one card each.")
+	} else if CalculateScore(hand.Cards) == 21 {
+		hand.Status = handStood
+		b.next("21!")
+	} else {
+		b.Message = "Hit or Stand?"
+	}
+	return b.Hands[i].Bet, nil
+}
+
+func (b *blackjackRound) insure(req blackjackActionRequest) (money.Amount, error) {
+	if b.InsuranceState != insuranceOffered {
+		return 0, illegalMove("Insurance is not on offer")
+	}
+	var stake money.Amount
+	if req.Accept {
+		// Insurance costs half the opening bet, rounded down so it is never
+		// more than half.
+		stake = b.Bet / 2
+		if stake == 0 {
+			return 0, illegalMove("This bet is too small to insure")
+		}
+		b.Insurance = stake
+		b.InsuranceState = insuranceTaken
+	} else {
+		b.InsuranceState = insuranceDeclined
+	}
+	b.peek()
+	return stake, nil
+}
+
+func (b *blackjackRound) surrender(req blackjackActionRequest) (money.Amount, error) {
+	if err := b.checkMove(); err != nil {
+		return 0, err
+	}
+	if !b.canSurrender() {
+		return 0, illegalMove("You can only surrender your opening hand")
+	}
+	hand := &b.Hands[0]
+	hand.Status, hand.Result = handSurrendered, resultSurrender
+	hand.WinAmount = hand.Bet.MulFrac(1, 2)
+	b.finish("You surrendered. Half your bet is returned.", 0)
+	return 0, nil
+}
+
+// expire finishes a round the player walked away from: insurance still on
+// offer is declined and every hand still in play stands.
+func (b *blackjackRound) expire() {
+	if b.InsuranceState == insuranceOffered {
+		b.InsuranceState = insuranceDeclined
+		b.peek()
+	}
+	if !b.GameOver {
+		for i := range b.Hands {
+			if b.Hands[i].Status == handPlaying {
+				b.Hands[i].Status = handStood
+			}
+		}
+		b.playDealer()
+	}
+	b.Message = "The round timed out. " + b.Message
+}
 
-	return models.GameState{
-		PlayerCards: playerCards,
-		DealerCards: dealerCards,
-		Deck:        deck,
-		Coins:       finalCoins,
-		Bet:         bet,
-		Message:     message,
-		GameOver:    true,
-		WinAmount:   winAmount,
+// next moves on to the following hand still in play, or to the dealer once
+// every hand is done.
+func (b *blackjackRound) next(message string) {
+	for i := b.ActiveHand + 1; i < len(b.Hands); i++ {
+		hand := &b.Hands[i]
+		if hand.Status != handPlaying {
+			continue
+		}
+		// A split hand dealt 21 stands as soon as it comes up.
+		if CalculateScore(hand.Cards) == 21 {
+			hand.Status = handStood
+			message = strings.TrimSpace(fmt.Sprintf("%s Hand %d has 21!", message, i+1))
+			continue
+		}
+		b.ActiveHand = i
+		b.Message = strings.TrimSpace(fmt.Sprintf("%s Playing hand %d of %d.", message, i+1, len(b.Hands)))
+		return
 	}
+	b.playDealer()
 }
 
-// ------------------- DB Update -------------------
+// playDealer draws the dealer's hand and settles every player hand.
+func (b *blackjackRound) playDealer() {
+	live := false
+	for _, h := range b.Hands {
+		if h.Status == handStood {
+			live = true
+		}
+	}
+
+	// Dealer hits until 17 or higher, and on soft 17 where the table says
+	// so, unless every hand is already lost
+	dealerScore, soft := calculateScore(b.DealerCards)
+	for live && (dealerScore < 17 || (dealerScore == 17 && soft && b.Rules.HitSoft17)) {
+		b.DealerCards = append(b.DealerCards, b.draw())
+		dealerScore, soft = calculateScore(b.DealerCards)
+	}
 
-func UpdateUserBalance(userID int, amount int) error {
-	_, err := database.DB.Exec(
-		"UPDATE users SET balance = GREATEST(balance + ?, 0) WHERE id = ?",
-		amount, userID,
-	)
-	return err
+	for i := range b.Hands {
+		hand := &b.Hands[i]
+		playerScore := CalculateScore(hand.Cards)
+		switch {
+		case hand.Status == handBust:
+			hand.Result = resultLose
+		case dealerScore > 21 || playerScore > dealerScore:
+			hand.Result, hand.WinAmount = resultWin, hand.Bet.Times(2)
+		case playerScore == dealerScore:
+			hand.Result, hand.WinAmount = resultPush, hand.Bet
+		default:
+			hand.Result = resultLose
+		}
+	}
+	b.finish(b.dealerMessage(dealerScore), 0)
 }
 
-// New function to set balance to absolute value
-func UpdateUserBalanceAbsolute(userID int, newBalance int) error {
-	_, err := database.DB.Exec(
-		"UPDATE users SET balance = GREATEST(?, 0) WHERE id = ?",
-		newBalance, userID,
-	)
-	return err
-}
\ No newline at end of file
+func (b *blackjackRound) dealerMessage(dealerScore int) string {
+	if len(b.Hands) == 1 {
+		switch hand := b.Hands[0]; {
+		case hand.Status == handBust:
+			return "BUST! You lose."
+		case dealerScore > 21:
+			return "Dealer busts! You win!"
+		case hand.Result == resultWin:
+			return "You win!"
+		case hand.Result == resultPush:
+			return "Push! It's a tie."
+		}
+		return "Dealer wins!"
+	}
+
+	var won, pushed int
+	for _, h := range b.Hands {
+		switch h.Result {
+		case resultWin:
+			won++
+		case resultPush:
+			pushed++
+		}
+	}
+	message := fmt.Sprintf("Dealer has %d.", dealerScore)
+	if dealerScore > 21 {
+		message = "Dealer busts!"
+	}
+	return fmt.Sprintf("%s You won %d, pushed %d and lost %d of %d hands.", message, won, pushed, len(b.Hands)-won-pushed, len(b.Hands))
+}
+
+// draw deals the next card from the table's shoe.
+func (b *blackjackRound) draw() models.Card {
+	return toBlackjackCard(b.shoe.Draw())
+}
+
+// finish ends the round. The payout is what every hand won, stakes included,
+// plus extra (the insurance payout).
+func (b *blackjackRound) finish(message string, extra money.Amount) {
+	b.GameOver = true
+	b.Message = message
+	b.WinAmount = extra
+	for _, h := range b.Hands {
+		b.WinAmount += h.WinAmount
+	}
+	if b.InsuranceState == insuranceOffered {
+		b.InsuranceState = insuranceDeclined
+	}
+}
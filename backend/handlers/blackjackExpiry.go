@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	defaultBlackjackRoundTimeout = 30 * time.Minute
+	// blackjackExpiryBatch is the most rounds one run expires.
+	blackjackExpiryBatch = 100
+)
+
+// BlackjackRoundTimeout is how long a round can sit without a move before it
+// is expired, set with BLACKJACK_ROUND_TIMEOUT (a Go duration such as "1h").
+// Until then its cards are kept out of the shoe.
+func BlackjackRoundTimeout() time.Duration {
+	if v := os.Getenv("BLACKJACK_ROUND_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute {
+			return d
+		}
+		log.Println("Invalid BLACKJACK_ROUND_TIMEOUT, using default:", v)
+	}
+	return defaultBlackjackRoundTimeout
+}
+
+// ExpireBlackjackRounds finishes the rounds left idle for longer than
+// BlackjackRoundTimeout, oldest first: their hands stand, the dealer plays
+// and the player is paid what they won. It returns how many it expired. A
+// round that fails is logged and retried on the next run.
+func ExpireBlackjackRounds(now time.Time) (int, error) {
+	cutoff := now.Add(-BlackjackRoundTimeout())
+	rows, err := database.DB.Query(`
+		SELECT id, user_id FROM blackjack_rounds
+		WHERE status = 'active' AND updated_at < ?
+		ORDER BY updated_at
+		LIMIT ?`, cutoff, blackjackExpiryBatch)
+	if err != nil {
+		return 0, err
+	}
+	type staleRound struct {
+		id     string
+		userID int
+	}
+	var stale []staleRound
+	for rows.Next() {
+		var r staleRound
+		if err := rows.Scan(&r.id, &r.userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stale = append(stale, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, r := range stale {
+		ok, err := expireBlackjackRound(r.userID, r.id, cutoff)
+		if err != nil {
+			log.Printf("Blackjack round %s expiry error: %v\n", r.id, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// expireBlackjackRound finishes one idle round in a transaction of its own.
+// It reports false if the player moved, or the round finished, in the
+// meantime.
+func expireBlackjackRound(userID int, id string, cutoff time.Time) (bool, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// The round is locked before the shoe, the same order every blackjack
+	// action takes.
+	var one int
+	err = tx.QueryRow(`
+		SELECT 1 FROM blackjack_rounds
+		WHERE id = ? AND status = 'active' AND updated_at < ?
+		FOR UPDATE`, id, cutoff).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	round, err := loadBlackjackRound(tx, userID, id)
+	if err != nil {
+		return false, err
+	}
+	if round.shoe, err = loadBlackjackShoe(tx, round.Rules, round.ID); err != nil {
+		return false, err
+	}
+
+	round.expire()
+	if err := saveBlackjackRound(tx, round); err != nil {
+		return false, err
+	}
+	if err := round.shoe.SaveTx(tx); err != nil {
+		return false, err
+	}
+	if _, err := wallet.PayoutTx(tx, userID, "Blackjack", round.ID, round.Currency, round.WinAmount); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Insurance states of a round.
+const (
+	insuranceOffered  = "offered"
+	insuranceTaken    = "taken"
+	insuranceDeclined = "declined"
+)
+
+// blackjackRound is a hand in play, stored in blackjack_rounds between
+// requests. Its ID doubles as the wallet round id of every stake placed on
+// it.
+type blackjackRound struct {
+	ID       string
+	UserID   int
+	Currency string
+	// Rules are fixed when the round is dealt.
+	Rules models.BlackjackRules
+	// Bet is the opening stake; doubles and splits add to the hands' bets.
+	Bet            money.Amount
+	Hands          []models.BlackjackHand
+	ActiveHand     int
+	DealerCards    []models.Card
+	Insurance      money.Amount
+	InsuranceState string
+	GameOver       bool
+	WinAmount      money.Amount
+	Message        string
+
+	// shoe is the table's shoe while the round is being played.
+	shoe *shoe.Shoe
+}
+
+// state is what the player is shown: only the dealer's up card
+// while the round is running.
+func (b *blackjackRound) state() models.GameState {
+	dealerCards := b.DealerCards
+	if !b.GameOver && len(dealerCards) > 1 {
+		dealerCards = dealerCards[:1]
+	}
+	hands := make([]models.BlackjackHand, len(b.Hands))
+	var bet money.Amount
+	for i, h := range b.Hands {
+		h.Score = CalculateScore(h.Cards)
+		hands[i] = h
+		bet += h.Bet
+	}
+	return models.GameState{
+		RoundID:          b.ID,
+		Rules:            b.Rules,
+		PlayerCards:      b.Hands[b.ActiveHand].Cards,
+		Hands:            hands,
+		ActiveHand:       b.ActiveHand,
+		DealerCards:      dealerCards,
+		Bet:              bet,
+		Insurance:        b.Insurance,
+		InsuranceOffered: b.InsuranceState == insuranceOffered,
+		Currency:         b.Currency,
+		Message:          b.Message,
+		GameOver:         b.GameOver,
+		WinAmount:        b.WinAmount,
+		Actions:          b.actions(),
+	}
+}
+
+func (b *blackjackRound) status() string {
+	if b.GameOver {
+		return "finished"
+	}
+	return "active"
+}
+
+func insertBlackjackRound(tx *sql.Tx, b *blackjackRound) error {
+	hands, dealer, err := b.marshalCards()
+	if err != nil {
+		return err
+	}
+	rules, err := json.Marshal(b.Rules)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO blackjack_rounds (id, user_id, currency, table_id, rules, bet, hands, active_hand, dealer_cards, insurance, insurance_state, status, win_amount, message, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		b.ID, b.UserID, b.Currency, b.Rules.Table, rules, b.Bet, hands, b.ActiveHand, dealer, b.Insurance, b.InsuranceState, b.status(), b.WinAmount, b.Message, now, now)
+	return err
+}
+
+func saveBlackjackRound(tx *sql.Tx, b *blackjackRound) error {
+	hands, dealer, err := b.marshalCards()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE blackjack_rounds
+		SET hands = ?, active_hand = ?, dealer_cards = ?, insurance = ?, insurance_state = ?,
+			status = ?, win_amount = ?, message = ?, updated_at = ?
+		WHERE id = ?`,
+		hands, b.ActiveHand, dealer, b.Insurance, b.InsuranceState, b.status(), b.WinAmount, b.Message, time.Now(), b.ID)
+	return err
+}
+
+// loadBlackjackRound reads one of the user's rounds and locks it until tx
+// ends. Rounds of other users are reported as sql.ErrNoRows.
+func loadBlackjackRound(tx *sql.Tx, userID int, id string) (*blackjackRound, error) {
+	b := &blackjackRound{}
+	var rules, hands, dealer []byte
+	var status string
+	err := tx.QueryRow(`
+		SELECT id, user_id, currency, rules, bet, hands, active_hand, dealer_cards, insurance, insurance_state, status, win_amount, message
+		FROM blackjack_rounds
+		WHERE id = ? AND user_id = ?
+		FOR UPDATE`, id, userID).Scan(&b.ID, &b.UserID, &b.Currency, &rules, &b.Bet, &hands, &b.ActiveHand, &dealer,
+		&b.Insurance, &b.InsuranceState, &status, &b.WinAmount, &b.Message)
+	if err != nil {
+		return nil, err
+	}
+	b.GameOver = status == "finished"
+	if err := json.Unmarshal(rules, &b.Rules); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(hands, &b.Hands); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(dealer, &b.DealerCards); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// loadBlackjackShoe locks the table's shoe and marks the cards of its open
+// rounds as in play: the round being played, if any, and every other round
+// that has not been idle long enough to expire.
+func loadBlackjackShoe(tx *sql.Tx, rules models.BlackjackRules, roundID string) (*shoe.Shoe, error) {
+	s, err := shoe.LoadTx(tx, rules.Table, rules.Decks, blackjackPenetration)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`
+		SELECT hands, dealer_cards FROM blackjack_rounds
+		WHERE table_id = ? AND status = 'active' AND (updated_at >= ? OR id = ?)`,
+		rules.Table, time.Now().Add(-BlackjackRoundTimeout()), roundID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var handsJSON, dealerJSON []byte
+		if err := rows.Scan(&handsJSON, &dealerJSON); err != nil {
+			return nil, err
+		}
+		var hands []models.BlackjackHand
+		var dealer []models.Card
+		if err := json.Unmarshal(handsJSON, &hands); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(dealerJSON, &dealer); err != nil {
+			return nil, err
+		}
+		for _, h := range hands {
+			dealer = append(dealer, h.Cards...)
+		}
+		for _, c := range dealer {
+			s.InPlay = append(s.InPlay, toShoeCard(c))
+		}
+	}
+	return s, rows.Err()
+}
+
+func (b *blackjackRound) marshalCards() (hands, dealer []byte, err error) {
+	if hands, err = json.Marshal(b.Hands); err != nil {
+		return
+	}
+	dealer, err = json.Marshal(b.DealerCards)
+	return
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"encoding/json"
+	"net/http"
+)
+
+const defaultBlackjackTable = "classic"
+
+var blackjackTables = map[string]models.BlackjackRules{
+	"classic": {
+		Table:            "classic",
+		Name:             "Classic",
+		Decks:            1,
+		BlackjackPays:    "3:2",
+		DoubleAfterSplit: true,
+		MaxSplits:        3,
+		MinBet:           money.Coins(1),
+	},
+	"vegas-strip": {
+		Table:            "vegas-strip",
+		Name:             "Vegas Strip",
+		Decks:            4,
+		BlackjackPays:    "3:2",
+		DoubleAfterSplit: true,
+		MaxSplits:        3,
+		MinBet:           money.Coins(5),
+		MaxBet:           money.Coins(1000),
+	},
+	"downtown": {
+		Table:            "downtown",
+		Name:             "Downtown",
+		Decks:            2,
+		HitSoft17:        true,
+		BlackjackPays:    "3:2",
+		DoubleAfterSplit: true,
+		MaxSplits:        3,
+		MinBet:           money.Coins(5),
+		MaxBet:           money.Coins(500),
+	},
+	"quick-6-5": {
+		Table:         "quick-6-5",
+		Name:          "Quick 6:5",
+		Decks:         6,
+		HitSoft17:     true,
+		BlackjackPays: "6:5",
+		MaxSplits:     1,
+		MinBet:        money.Coins(1),
+		MaxBet:        money.Coins(100),
+	},
+	"high-limit": {
+		Table:            "high-limit",
+		Name:             "High Limit",
+		Decks:            6,
+		BlackjackPays:    "3:2",
+		DoubleAfterSplit: true,
+		MaxSplits:        3,
+		MinBet:           money.Coins(100),
+		MaxBet:           money.Coins(5000),
+	},
+}
+
+// blackjackTableOrder lists the tables in display order.
+var blackjackTableOrder = []string{"classic", "vegas-strip", "downtown", "quick-6-5", "high-limit"}
+
+// blackjackPayouts are the supported payouts for a natural, as the fraction
+// of the stake won.
+var blackjackPayouts = map[string][2]int64{
+	"3:2": {3, 2},
+	"6:5": {6, 5},
+}
+
+// lookupBlackjackTable resolves a table name, treating an empty name as the
+// default table.
+func lookupBlackjackTable(table string) (models.BlackjackRules, bool) {
+	if table == "" {
+		table = defaultBlackjackTable
+	}
+	rules, ok := blackjackTables[table]
+	return rules, ok
+}
+
+// ListBlackjackTables godoc
+// @Summary List blackjack tables
+// @Description Returns the blackjack tables and the rules each is played under
+// @Tags blackjack
+// @Produce json
+// @Success 200 {array} models.BlackjackRules
+// @Router /api/v1/blackjack/tables [get]
+func ListBlackjackTables(w http.ResponseWriter, r *http.Request) {
+	tables := make([]models.BlackjackRules, 0, len(blackjackTableOrder))
+	for _, t := range blackjackTableOrder {
+		tables = append(tables, blackjackTables[t])
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tables)
+}
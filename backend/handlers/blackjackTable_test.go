@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"casino-hub/backend/money"
+	"errors"
+	"testing"
+)
+
+func TestBlackjackTables(t *testing.T) {
+	if len(blackjackTableOrder) != len(blackjackTables) {
+		t.Errorf("%d tables listed, %d configured", len(blackjackTableOrder), len(blackjackTables))
+	}
+	for _, name := range blackjackTableOrder {
+		rules, ok := blackjackTables[name]
+		if !ok {
+			t.Errorf("listed table %q is not configured", name)
+			continue
+		}
+		if rules.Table != name {
+			t.Errorf("table %q calls itself %q", name, rules.Table)
+		}
+		if _, ok := blackjackPayouts[rules.BlackjackPays]; !ok {
+			t.Errorf("table %q pays an unknown %q for blackjack", name, rules.BlackjackPays)
+		}
+		if rules.Decks < 1 {
+			t.Errorf("table %q deals from %d decks", name, rules.Decks)
+		}
+		if rules.MinBet <= 0 || (rules.MaxBet > 0 && rules.MaxBet < rules.MinBet) {
+			t.Errorf("table %q takes bets from %s to %s", name, rules.MinBet, rules.MaxBet)
+		}
+		if rules.MaxSplits < 0 {
+			t.Errorf("table %q allows %d splits", name, rules.MaxSplits)
+		}
+	}
+}
+
+func TestLookupBlackjackTable(t *testing.T) {
+	if rules, ok := lookupBlackjackTable(""); !ok || rules.Table != defaultBlackjackTable {
+		t.Errorf("no table = %q, %v, want %q", rules.Table, ok, defaultBlackjackTable)
+	}
+	if rules, ok := lookupBlackjackTable("downtown"); !ok || rules.Table != "downtown" {
+		t.Errorf("downtown = %q, %v", rules.Table, ok)
+	}
+	if _, ok := lookupBlackjackTable("Classic"); ok {
+		t.Error("table names are matched ignoring case")
+	}
+}
+
+func TestBlackjackTableRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		table     string
+		ranks     []int
+		moves     []string
+		results   []string
+		winAmount money.Amount
+	}{
+		{"3:2 blackjack", "classic", []int{1, 10, 13, 7}, nil, []string{resultBlackjack}, 2500},
+		{"6:5 blackjack", "quick-6-5", []int{1, 10, 13, 7}, nil, []string{resultBlackjack}, 2200},
+		// Dealer 6 A is a soft 17.
+		{"stands on soft 17", "classic", []int{10, 6, 8, 1}, []string{"stand"}, []string{resultWin}, 2000},
+		{"hits soft 17", "downtown", []int{10, 6, 8, 1, 2}, []string{"stand"}, []string{resultLose}, 0},
+		// Dealer 10 7 is a hard 17 on every table.
+		{"stands on hard 17", "downtown", []int{10, 10, 8, 7}, []string{"stand"}, []string{resultWin}, 2000},
+		{"split once", "quick-6-5", []int{9, 10, 9, 8, 2, 10, 13}, []string{"split", "hit", "stand"}, []string{resultWin, resultWin}, 4000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedTableRound(t, tt.table, 1000, tt.ranks...)
+			for _, move := range tt.moves {
+				if _, err := blackjackMoves[move](b, blackjackActionRequest{}); err != nil {
+					t.Fatalf("%s: %v", move, err)
+				}
+			}
+			if !b.GameOver {
+				t.Fatalf("round not over: %s", b.Message)
+			}
+			for i, h := range b.Hands {
+				if h.Result != tt.results[i] {
+					t.Errorf("hand %d %s, want %s", i+1, h.Result, tt.results[i])
+				}
+			}
+			if b.WinAmount != tt.winAmount {
+				t.Errorf("round pays %s, want %s", b.WinAmount, tt.winAmount)
+			}
+			if b.shoe.Position != len(tt.ranks) {
+				t.Errorf("dealt %d of the %d stacked cards", b.shoe.Position, len(tt.ranks))
+			}
+		})
+	}
+}
+
+func TestBlackjackTableLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		ranks []int
+		moves []string // the last one is refused
+	}{
+		{"no double after a split", "quick-6-5", []int{8, 10, 8, 7, 3, 10}, []string{"split", "double"}},
+		{"one split", "quick-6-5", []int{8, 10, 8, 7, 8, 10}, []string{"split", "split"}},
+		{"three splits", "classic", []int{8, 10, 8, 7, 8, 8, 8, 8, 8, 8}, []string{"split", "split", "split", "split"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedTableRound(t, tt.table, 1000, tt.ranks...)
+			last := len(tt.moves) - 1
+			for _, move := range tt.moves[:last] {
+				if _, err := blackjackMoves[move](b, blackjackActionRequest{}); err != nil {
+					t.Fatalf("%s: %v", move, err)
+				}
+			}
+			for _, a := range b.actions() {
+				if a == tt.moves[last] {
+					t.Errorf("%s is offered", a)
+				}
+			}
+			if _, err := blackjackMoves[tt.moves[last]](b, blackjackActionRequest{}); !errors.As(err, new(illegalMove)) {
+				t.Errorf("%s: err = %v, want an illegal move", tt.moves[last], err)
+			}
+			if want := b.Rules.MaxSplits + 1; tt.moves[last] == "split" && len(b.Hands) != want {
+				t.Errorf("%d hands, want %d", len(b.Hands), want)
+			}
+		})
+	}
+}
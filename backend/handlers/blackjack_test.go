@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// stackedRound deals a round on the classic table from a shoe holding ranks
+// in dealing order: player, dealer, player, dealer, then every later draw.
+func stackedRound(t *testing.T, bet money.Amount, ranks ...int) *blackjackRound {
+	t.Helper()
+	return stackedTableRound(t, defaultBlackjackTable, bet, ranks...)
+}
+
+// stackedTableRound is stackedRound on the given table.
+func stackedTableRound(t *testing.T, table string, bet money.Amount, ranks ...int) *blackjackRound {
+	t.Helper()
+	rules, ok := blackjackTables[table]
+	if !ok {
+		t.Fatalf("no table %q", table)
+	}
+	cards := make([]shoe.Card, len(ranks))
+	for i, r := range ranks {
+		cards[i] = shoe.Card{Rank: r, Suit: "♠"}
+	}
+	b := &blackjackRound{
+		Rules: rules,
+		Bet:   bet,
+		shoe:  &shoe.Shoe{Table: rules.Table, Decks: rules.Decks, Cards: cards},
+	}
+	hand := models.BlackjackHand{Bet: bet, Status: handPlaying}
+	for range 2 {
+		hand.Cards = append(hand.Cards, b.draw())
+		b.DealerCards = append(b.DealerCards, b.draw())
+	}
+	b.Hands = []models.BlackjackHand{hand}
+	b.deal()
+	return b
+}
+
+func TestInsurance(t *testing.T) {
+	tests := []struct {
+		name      string
+		bet       money.Amount
+		ranks     []int
+		accept    bool
+		stake     money.Amount
+		gameOver  bool
+		winAmount money.Amount
+	}{
+		// Player 9 8 against dealer A K.
+		{"taken against dealer blackjack pays 2:1", 1000, []int{9, 1, 8, 13}, true, 500, true, 1500},
+		{"declined against dealer blackjack", 1000, []int{9, 1, 8, 13}, false, 0, true, 0},
+		// Player 9 8 against dealer A 7.
+		{"taken and lost", 1000, []int{9, 1, 8, 7}, true, 500, false, 0},
+		{"odd bet rounds down", 1001, []int{9, 1, 8, 7}, true, 500, false, 0},
+		{"smallest bet that can be insured", 2, []int{9, 1, 8, 7}, true, 1, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedRound(t, tt.bet, tt.ranks...)
+			if b.InsuranceState != insuranceOffered {
+				t.Fatalf("insurance state = %q, want %q", b.InsuranceState, insuranceOffered)
+			}
+			stake, err := b.insure(blackjackActionRequest{Accept: tt.accept})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stake != tt.stake || b.Insurance != tt.stake {
+				t.Errorf("stake = %s, insurance = %s, want %s", stake, b.Insurance, tt.stake)
+			}
+			if b.GameOver != tt.gameOver || b.WinAmount != tt.winAmount {
+				t.Errorf("game over = %v paying %s, want %v paying %s", b.GameOver, b.WinAmount, tt.gameOver, tt.winAmount)
+			}
+		})
+	}
+}
+
+func TestInsuranceTooSmall(t *testing.T) {
+	b := stackedRound(t, 1, 9, 1, 8, 7)
+	if b.InsuranceState != "" {
+		t.Errorf("insurance state = %q on a bet of %s, want none", b.InsuranceState, b.Bet)
+	}
+	if _, err := b.insure(blackjackActionRequest{Accept: true}); !errors.As(err, new(illegalMove)) {
+		t.Errorf("insuring a bet of %s: err = %v, want an illegal move", b.Bet, err)
+	}
+
+	// A round stored while the offer was still made on such bets.
+	b.InsuranceState = insuranceOffered
+	stake, err := b.insure(blackjackActionRequest{Accept: true})
+	if !errors.As(err, new(illegalMove)) || stake != 0 || b.InsuranceState != insuranceOffered {
+		t.Errorf("insure = %s, %v with state %q, want an illegal move", stake, err, b.InsuranceState)
+	}
+	if _, err := b.insure(blackjackActionRequest{}); err != nil || b.InsuranceState != insuranceDeclined {
+		t.Errorf("declining: err = %v, state %q", err, b.InsuranceState)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	tests := []struct {
+		name      string
+		ranks     []int
+		result    string
+		winAmount money.Amount
+	}{
+		// Player 9 8 against dealer A K, with insurance still on offer.
+		{"insurance declined against dealer blackjack", []int{9, 1, 8, 13}, resultLose, 0},
+		// Player 10 9 against dealer 10 7.
+		{"hand stands and wins", []int{10, 10, 9, 7}, resultWin, 2000},
+		// Player 10 9 against dealer 6 5, who draws a 10.
+		{"dealer plays out", []int{10, 6, 9, 5, 10}, resultLose, 0},
+		// Player 2 3 against dealer A 7, who stands on soft 18.
+		{"insurance declined and hand stands", []int{2, 1, 3, 7}, resultLose, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedRound(t, 1000, tt.ranks...)
+			b.expire()
+			if !b.GameOver || b.InsuranceState == insuranceOffered {
+				t.Fatalf("round not finished: game over = %v, insurance = %q", b.GameOver, b.InsuranceState)
+			}
+			if h := b.Hands[0]; h.Status == handPlaying || h.Result != tt.result || b.WinAmount != tt.winAmount {
+				t.Errorf("hand %s %s paying %s, want %s paying %s", h.Status, h.Result, b.WinAmount, tt.result, tt.winAmount)
+			}
+			if b.shoe.Position != len(tt.ranks) {
+				t.Errorf("dealt %d of the %d stacked cards", b.shoe.Position, len(tt.ranks))
+			}
+		})
+	}
+}
+
+// blackjackMoves are the player's moves by action name.
+var blackjackMoves = map[string]func(*blackjackRound, blackjackActionRequest) (money.Amount, error){
+	"hit":       (*blackjackRound).hit,
+	"stand":     (*blackjackRound).stand,
+	"double":    (*blackjackRound).double,
+	"split":     (*blackjackRound).split,
+	"surrender": (*blackjackRound).surrender,
+	"insurance": (*blackjackRound).insure,
+}
+
+func blackjackCards(ranks ...int) []models.Card {
+	cards := make([]models.Card, len(ranks))
+	for i, r := range ranks {
+		cards[i] = toBlackjackCard(shoe.Card{Rank: r, Suit: "♥"})
+	}
+	return cards
+}
+
+func TestCalculateScore(t *testing.T) {
+	tests := []struct {
+		ranks     []int
+		score     int
+		soft      bool
+		blackjack bool
+	}{
+		{[]int{1, 13}, 21, true, true},
+		{[]int{10, 1}, 21, true, true},
+		{[]int{1, 1}, 12, true, false},
+		{[]int{1, 1, 9}, 21, true, false},
+		{[]int{1, 6}, 17, true, false},
+		{[]int{1, 6, 10}, 17, false, false},
+		{[]int{1, 1, 1, 1}, 14, true, false},
+		{[]int{11, 12, 13}, 30, false, false},
+		{[]int{5, 6, 10}, 21, false, false},
+		{[]int{2, 3}, 5, false, false},
+	}
+	for _, tt := range tests {
+		cards := blackjackCards(tt.ranks...)
+		score, soft := calculateScore(cards)
+		if score != tt.score || soft != tt.soft {
+			t.Errorf("%v scores %d (soft %v), want %d (soft %v)", tt.ranks, score, soft, tt.score, tt.soft)
+		}
+		if got := IsBlackjack(cards); got != tt.blackjack {
+			t.Errorf("IsBlackjack(%v) = %v, want %v", tt.ranks, got, tt.blackjack)
+		}
+	}
+}
+
+func TestBlackjackRound(t *testing.T) {
+	tests := []struct {
+		name      string
+		ranks     []int // player, dealer, player, dealer, then every later draw
+		moves     []string
+		stakes    []money.Amount
+		results   []string
+		winAmount money.Amount
+	}{
+		{"player blackjack pays 3:2", []int{1, 10, 13, 7}, nil, nil, []string{resultBlackjack}, 2500},
+		{"dealer blackjack", []int{10, 10, 9, 1}, nil, nil, []string{resultLose}, 0},
+		{"both blackjack", []int{1, 10, 13, 1}, nil, nil, []string{resultPush}, 1000},
+		{"stand and win", []int{10, 10, 9, 7}, []string{"stand"}, []money.Amount{0}, []string{resultWin}, 2000},
+		{"stand and push", []int{10, 10, 7, 7}, []string{"stand"}, []money.Amount{0}, []string{resultPush}, 1000},
+		{"stand and lose", []int{10, 10, 6, 8}, []string{"stand"}, []money.Amount{0}, []string{resultLose}, 0},
+		{"dealer draws and busts", []int{10, 10, 8, 6, 10}, []string{"stand"}, []money.Amount{0}, []string{resultWin}, 2000},
+		{"hit then stand", []int{10, 10, 5, 7, 4}, []string{"hit", "stand"}, []money.Amount{0, 0}, []string{resultWin}, 2000},
+		// The dealer does not draw to a busted hand.
+		{"hit and bust", []int{10, 10, 6, 6, 13}, []string{"hit"}, []money.Amount{0}, []string{resultLose}, 0},
+		{"hit to 21 stands", []int{10, 10, 6, 8, 5}, []string{"hit"}, []money.Amount{0}, []string{resultWin}, 2000},
+		{"double", []int{6, 10, 5, 7, 10}, []string{"double"}, []money.Amount{1000}, []string{resultWin}, 4000},
+		{"double and bust", []int{10, 10, 6, 6, 10}, []string{"double"}, []money.Amount{1000}, []string{resultLose}, 0},
+		{"surrender", []int{10, 10, 6, 7}, []string{"surrender"}, []money.Amount{0}, []string{resultSurrender}, 500},
+		{"split and play both hands", []int{8, 10, 8, 7, 3, 10, 10}, []string{"split", "hit", "stand"},
+			[]money.Amount{1000, 0, 0}, []string{resultWin, resultWin}, 4000},
+		{"split aces get one card each", []int{1, 10, 1, 7, 9, 13}, []string{"split"},
+			[]money.Amount{1000}, []string{resultWin, resultWin}, 4000},
+		{"split hand dealt 21 stands", []int{10, 10, 10, 7, 1, 5}, []string{"split", "stand"},
+			[]money.Amount{1000, 0}, []string{resultWin, resultLose}, 2000},
+		{"second split hand dealt 21 is skipped", []int{10, 10, 10, 7, 5, 1}, []string{"split", "stand"},
+			[]money.Amount{1000, 0}, []string{resultLose, resultWin}, 2000},
+		{"double after split", []int{8, 10, 8, 7, 3, 10, 10}, []string{"split", "double", "stand"},
+			[]money.Amount{1000, 1000, 0}, []string{resultWin, resultWin}, 6000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedRound(t, 1000, tt.ranks...)
+			for i, move := range tt.moves {
+				if b.GameOver {
+					t.Fatalf("round over before %s", move)
+				}
+				stake, err := blackjackMoves[move](b, blackjackActionRequest{})
+				if err != nil {
+					t.Fatalf("%s: %v", move, err)
+				}
+				if stake != tt.stakes[i] {
+					t.Errorf("%s took a stake of %s, want %s", move, stake, tt.stakes[i])
+				}
+			}
+			if !b.GameOver {
+				t.Fatalf("round not over: %s", b.Message)
+			}
+			if len(b.Hands) != len(tt.results) {
+				t.Fatalf("%d hands, want %d", len(b.Hands), len(tt.results))
+			}
+			for i, h := range b.Hands {
+				if h.Status == handPlaying || h.Result != tt.results[i] {
+					t.Errorf("hand %d %s %s, want %s", i+1, h.Status, h.Result, tt.results[i])
+				}
+			}
+			if b.WinAmount != tt.winAmount {
+				t.Errorf("round pays %s, want %s", b.WinAmount, tt.winAmount)
+			}
+			if b.shoe.Position != len(tt.ranks) {
+				t.Errorf("dealt %d of the %d stacked cards", b.shoe.Position, len(tt.ranks))
+			}
+			if actions := b.actions(); len(actions) != 0 {
+				t.Errorf("finished round offers %v", actions)
+			}
+		})
+	}
+}
+
+func TestIllegalBlackjackMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		ranks []int
+		moves []string // the last one is refused
+	}{
+		{"double after a hit", []int{10, 10, 2, 7, 3}, []string{"hit", "double"}},
+		{"split a pair of different ranks", []int{10, 10, 13, 7}, []string{"split"}},
+		{"surrender after a hit", []int{10, 10, 2, 7, 3}, []string{"hit", "surrender"}},
+		{"surrender after a split", []int{8, 10, 8, 7, 3, 10}, []string{"split", "surrender"}},
+		{"hit before answering insurance", []int{10, 1, 9, 7}, []string{"hit"}},
+		{"stand before answering insurance", []int{10, 1, 9, 7}, []string{"stand"}},
+		{"insurance not on offer", []int{10, 10, 9, 7}, []string{"insurance"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedRound(t, 1000, tt.ranks...)
+			last := len(tt.moves) - 1
+			for _, move := range tt.moves[:last] {
+				if _, err := blackjackMoves[move](b, blackjackActionRequest{}); err != nil {
+					t.Fatalf("%s: %v", move, err)
+				}
+			}
+			dealt, hands := b.shoe.Position, len(b.Hands)
+			stake, err := blackjackMoves[tt.moves[last]](b, blackjackActionRequest{})
+			if !errors.As(err, new(illegalMove)) {
+				t.Fatalf("%s: err = %v, want an illegal move", tt.moves[last], err)
+			}
+			if stake != 0 || b.shoe.Position != dealt || len(b.Hands) != hands || b.GameOver {
+				t.Errorf("refused %s changed the round: stake %s, %d cards dealt, %d hands, game over %v",
+					tt.moves[last], stake, b.shoe.Position, len(b.Hands), b.GameOver)
+			}
+		})
+	}
+}
+
+func TestBlackjackActions(t *testing.T) {
+	tests := []struct {
+		name  string
+		ranks []int
+		moves []string
+		want  []string
+	}{
+		{"pair", []int{8, 10, 8, 7}, nil, []string{"hit", "stand", "double", "split", "surrender"}},
+		{"no pair", []int{10, 10, 9, 7}, nil, []string{"hit", "stand", "double", "surrender"}},
+		{"after a hit", []int{10, 10, 2, 7, 3}, []string{"hit"}, []string{"hit", "stand"}},
+		{"insurance on offer", []int{10, 1, 9, 7}, nil, []string{"insurance"}},
+		{"after a split", []int{8, 10, 8, 7, 3, 10}, []string{"split"}, []string{"hit", "stand", "double"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := stackedRound(t, 1000, tt.ranks...)
+			for _, move := range tt.moves {
+				if _, err := blackjackMoves[move](b, blackjackActionRequest{}); err != nil {
+					t.Fatalf("%s: %v", move, err)
+				}
+			}
+			if got := b.actions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("actions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// errorResponse is the JSON error body for failures a client has to tell
+// apart, such as a limit being hit. Code is stable; Error is for display.
+type errorResponse struct {
+	Error   string `json:"error"`
+	Code    string `json:"code"`
+	Details any    `json:"details,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: message, Code: code, Details: details})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"casino-hub/backend/exclusion"
+	"casino-hub/backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// GetSelfExclusion godoc
+// @Summary Get self-exclusion status
+// @Description Returns the exclusion in force, if any, and every exclusion the user has requested
+// @Tags self-exclusion
+// @Produce json
+// @Success 200 {object} models.SelfExclusionStatus
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/self-exclusion [get]
+func GetSelfExclusion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	active, err := exclusion.Active(userID, time.Now())
+	if err != nil {
+		log.Println("GetSelfExclusion error:", err)
+		http.Error(w, "Failed to fetch self-exclusion", http.StatusInternalServerError)
+		return
+	}
+	history, err := exclusion.History(userID)
+	if err != nil {
+		log.Println("GetSelfExclusion error:", err)
+		http.Error(w, "Failed to fetch self-exclusion", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SelfExclusionStatus{Active: active, History: history})
+}
+
+// SelfExclude godoc
+// @Summary Self-exclude
+// @Description Blocks the user from all gaming for 24h, 7d, 6m or permanently. An exclusion cannot be lifted or shortened.
+// @Tags self-exclusion
+// @Accept json
+// @Produce json
+// @Param request body object true "period: 24h, 7d, 6m or permanent"
+// @Success 201 {object} models.SelfExclusion
+// @Failure 400 {string} string "Invalid period"
+// @Failure 409 {string} string "A longer exclusion is already active"
+// @Router /api/v1/self-exclusion [post]
+func SelfExclude(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Period string `json:"period"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	e, err := exclusion.Exclude(userID, req.Period)
+	switch {
+	case errors.Is(err, exclusion.ErrInvalidPeriod):
+		writeError(w, http.StatusBadRequest, "invalid_period", "Period must be 24h, 7d, 6m or permanent", nil)
+		return
+	case errors.Is(err, exclusion.ErrAlreadyExcluded):
+		writeError(w, http.StatusConflict, "exclusion_active", "An exclusion ending later is already in place", nil)
+		return
+	case err != nil:
+		log.Println("SelfExclude error:", err)
+		http.Error(w, "Failed to self-exclude", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(e)
+}
@@ -3,46 +3,81 @@ package handlers
 import (
 	"casino-hub/backend/database"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"casino-hub/backend/wallet"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"net/http"
 )
 
+// StartHiLo takes the stake and deals the first card of a new round. A
+// player has at most one round in play.
+func StartHiLo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-var currentCard = getRandomCard()
+	var req models.HiLoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Bet <= 0 {
+		http.Error(w, "Invalid bet", http.StatusBadRequest)
+		return
+	}
 
-func getRandomCard() models.HiLoCard {
-	suit := models.HiLoSuits[rand.Intn(len(suits))]
-	return models.HiLoCard{
-		Value: rand.Intn(13) + 1,
-		Suit:  suit.Symbol,
-		Color: suit.Color,
-	} 
-}
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
 
-func calculatePayout(bet int, currentCard models.HiLoCard, guess string, streak int) int {
-	var odds float64
-	if guess == "higher"{
-		cardsHigher := 13 - currentCard.Value
-		odds = float64(cardsHigher) / 12.0
-	} else {
-		cardsLower := currentCard.Value - 1
-		odds = float64(cardsLower) / 12.0
+	if _, err := loadActiveHiLoRound(tx, userID); err == nil {
+		http.Error(w, "A round is already in play", http.StatusConflict)
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Println("HiLo round error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
 
-	baseOdds := 1.0
-	if odds > 0 && odds < 0.5{
-		baseOdds = (1 / odds) - 1
+	round := newHiLoRound(wallet.NewRoundID(), userID, req.Currency, req.Bet)
+	// The stake stays reserved in the pot until the round is cashed out or
+	// lost.
+	settlement, ok := settleBetTx(w, tx, wallet.Bet{UserID: userID, Game: "HiLo", Currency: req.Currency, RoundID: round.ID, Stake: req.Bet}, func(tx *sql.Tx) (money.Amount, error) {
+		if round.Currency == "" {
+			round.Currency = wallet.DefaultCurrency
+		}
+		return 0, insertHiLoRound(tx, round)
+	})
+	if !ok {
+		return
 	}
-	multiplier := 1 + float64(streak)*0.1
-	if multiplier > 5 {
-		multiplier = 5
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
 
-	return int(float64(bet) * baseOdds * multiplier)
+	resp := round.state()
+	resp.Balance = settlement.Balance
+	resp.Currency = settlement.Currency
+	resp.RealityCheckDue = settlement.RealityCheckDue
+	resp.Message = "Higher or lower?"
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
+// PlayHiLo guesses the next card of the player's round. A right guess grows
+// the pot by the guess's odds; a wrong one loses it.
 func PlayHiLo(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserID(r.Context())
 	if !ok {
@@ -55,93 +90,171 @@ func PlayHiLo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.Guess != "higher" && req.Guess != "lower" && req.Guess != "tie" {
+		http.Error(w, "Invalid guess type", http.StatusBadRequest)
+		return
+	}
 
-	var balance, streak int
-	err := database.DB.QueryRow("SELECT balance FROM users WHERE id = ?", userID).Scan(&balance)
-	if err != nil {
-		http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
+	tx, round, ok := beginHiLoRound(w, userID)
+	if !ok {
 		return
 	}
+	defer tx.Rollback()
 
-	if req.Bet <= 0 || req.Bet > balance {
-		http.Error(w, "Invalid bet", http.StatusBadRequest)
+	pot := round.Pot
+	from, to, won := round.guess(req.Guess)
+	balance, ok := finishHiLoRequest(w, tx, round)
+	if !ok {
 		return
 	}
 
-	balance -= req.Bet
+	// A lost round credits nothing; the stake was taken when it started.
+	var payout money.Amount
+	if won {
+		payout = round.Pot - pot
+	}
+	cardFrom, cardTo := toHiLoCard(from), toHiLoCard(to)
+	resp := round.state()
+	resp.CardFrom = &cardFrom
+	resp.CardTo = &cardTo
+	resp.Guess = req.Guess
+	resp.Won = won
+	resp.Payout = payout
+	resp.Balance = balance
+	resp.Message = getMessage(won, from, to, payout)
 
-	nextCard := getRandomCard()
-	won := false
-	var payout int
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
 
-	switch req.Guess {
-	case "higher":
-		if nextCard.Value > currentCard.Value {
-			won = true
-			payout = calculatePayout(req.Bet, currentCard, req.Guess, streak)
-		}
-	case "lower":
-		if nextCard.Value < currentCard.Value {
-			won = true
-			payout = calculatePayout(req.Bet, currentCard, req.Guess, streak)
-		}
-	case "tie":
-		if nextCard.Value == currentCard.Value {
-			won = true
-			payout = req.Bet * 10
-		}
-	default:
-		http.Error(w, "Invalid guess type", http.StatusBadRequest)
+// CashOutHiLo ends the player's round and banks the pot. A round can only be
+// cashed out once a guess has won; otherwise the stake would count towards
+// bonus wagering and limits without ever being at risk.
+func CashOutHiLo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	if won {
-		balance += req.Bet + payout
-		streak++
-	} else {
-		payout = -req.Bet
-		streak = 0
+	tx, round, ok := beginHiLoRound(w, userID)
+	if !ok {
+		return
 	}
+	defer tx.Rollback()
 
-	_, err = database.DB.Exec("UPDATE users SET balance = ? WHERE id = ?", balance, userID)
-	if err != nil {
-		http.Error(w, "Failed to update balance", http.StatusInternalServerError)
+	if err := round.cashOut(); errors.Is(err, errHiLoNothingWon) {
+		http.Error(w, "Win at least one guess before cashing out", http.StatusConflict)
+		return
+	}
+	balance, ok := finishHiLoRequest(w, tx, round)
+	if !ok {
 		return
 	}
 
-	if err := RecordGamePlay(userID, "HiLo"); err != nil {
-		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
+	resp := round.state()
+	resp.Payout = round.Pot
+	resp.Balance = balance
+	resp.Message = fmt.Sprintf("💰 Cashed out %s", round.Pot)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetHiLoRound returns the player's round in play.
+func GetHiLoRound(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	resp := models.HiLoResponse{
-		CardFrom: currentCard,
-		CardTo:   nextCard,
-		Guess:    req.Guess,
-		Won:      won,
-		Payout:   payout,
-		Balance:  balance,
-		Streak:   streak,
-		Message:  getMessage(won, nextCard, req.Guess, payout, streak),
+	tx, round, ok := beginHiLoRound(w, userID)
+	if !ok {
+		return
 	}
+	tx.Rollback()
 
-	currentCard = nextCard
+	balance, _, err := wallet.Balances(userID, round.Currency)
+	if err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	resp := round.state()
+	resp.Balance = balance
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-func getMessage(won bool, next models.HiLoCard, guess string, payout int, streak int) string {
+// beginHiLoRound opens a transaction holding the player's round in play. It
+// writes the error response itself when ok is false.
+func beginHiLoRound(w http.ResponseWriter, userID int) (*sql.Tx, *hiloRound, bool) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	round, err := loadActiveHiLoRound(tx, userID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "No round in play", http.StatusNotFound)
+			return nil, nil, false
+		}
+		log.Println("HiLo round error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return tx, round, true
+}
+
+// finishHiLoRequest stores the round and commits. A round that has ended is
+// paid its pot in the same transaction, so it cannot be paid twice. It
+// returns the player's balance afterwards.
+func finishHiLoRequest(w http.ResponseWriter, tx *sql.Tx, round *hiloRound) (money.Amount, bool) {
+	if err := saveHiLoRound(tx, round); err != nil {
+		log.Println("HiLo round error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	// A round still in play is paid nothing; the balance is read in tx all
+	// the same, so nothing after the commit can fail.
+	over := round.Status != hiloActive
+	var payout money.Amount
+	if over {
+		payout = round.Pot
+	}
+	t, err := wallet.PayoutTx(tx, round.UserID, "HiLo", round.ID, round.Currency, payout)
+	if err != nil {
+		writeSettlementError(w, err)
+		return 0, false
+	}
+	balance := t.BalanceAfter
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !over {
+		return balance, true
+	}
+	if err := RecordGamePlay(round.UserID, "HiLo"); err != nil {
+		fmt.Println("RecordGamePlay error:", err)
+	}
+	return balance, true
+}
+
+func getMessage(won bool, from, to shoe.Card, payout money.Amount) string {
 	if won {
-		if payout >= 3 {
+		if payout >= money.Coins(3) {
 			return "🔥 BIG WIN!"
 		}
 		return "✅ Winner!"
 	}
-	if next.Value == currentCard.Value {
+	if to.Rank == from.Rank {
 		return "❌ Tie, you lose!"
 	}
 	return "❌ Wrong guess!"
 }
-
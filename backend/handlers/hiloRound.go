@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// HiLo round statuses.
+const (
+	hiloActive    = "active"
+	hiloCashedOut = "cashed_out"
+	hiloLost      = "lost"
+)
+
+var errHiLoNothingWon = errors.New("hilo: no guess has won yet")
+
+// hiloReturn is the share of the fair odds a correct guess pays, in percent.
+const hiloReturn = 97
+
+// hiloRound is a player's HiLo game, stored in hilo_rounds between requests.
+// Every round deals from its own deck, so the odds of each guess depend on the
+// cards already seen. Its ID is the wallet round id of the stake.
+type hiloRound struct {
+	ID       string
+	UserID   int
+	Currency string
+	Bet      money.Amount
+	// Pot is what cashing out pays. It starts at the bet and is multiplied by
+	// the odds of every correct guess.
+	Pot         money.Amount
+	Streak      int
+	CurrentCard shoe.Card
+	Status      string
+
+	deck *shoe.Shoe
+}
+
+func newHiLoRound(id string, userID int, currency string, bet money.Amount) *hiloRound {
+	h := &hiloRound{ID: id, UserID: userID, Currency: currency, Bet: bet, Pot: bet, Status: hiloActive}
+	h.deck = shoe.New("hilo", 1, 1)
+	h.CurrentCard = h.deck.Draw()
+	return h
+}
+
+// winners counts the cards left in the deck that make guess right.
+func (h *hiloRound) winners(guess string) int64 {
+	var n int64
+	for _, c := range h.deck.Cards[h.deck.Position:] {
+		switch {
+		case guess == "higher" && c.Rank > h.CurrentCard.Rank,
+			guess == "lower" && c.Rank < h.CurrentCard.Rank,
+			guess == "tie" && c.Rank == h.CurrentCard.Rank:
+			n++
+		}
+	}
+	return n
+}
+
+// calculatePayout is the pot after a correct guess: the fair odds of the
+// guess against the cards left in the deck, less the house's share. The pot
+// compounding with every correct guess is what rewards a streak.
+func (h *hiloRound) calculatePayout(guess string) money.Amount {
+	winners := h.winners(guess)
+	if winners == 0 {
+		return 0
+	}
+	return h.Pot.MulFrac(int64(h.deck.Remaining())*hiloReturn, winners*100)
+}
+
+func (h *hiloRound) odds() *models.HiLoOdds {
+	multiplier := func(guess string) float64 {
+		winners := h.winners(guess)
+		if winners == 0 {
+			return 0
+		}
+		return float64(h.deck.Remaining()) * hiloReturn / 100 / float64(winners)
+	}
+	return &models.HiLoOdds{Higher: multiplier("higher"), Lower: multiplier("lower"), Tie: multiplier("tie")}
+}
+
+// guess draws the next card and settles the guess against it. A wrong guess
+// loses the round; a right one grows the pot and carries on from the new
+// card.
+func (h *hiloRound) guess(guess string) (from, to shoe.Card, won bool) {
+	// A spent deck is shuffled back together, all but the card showing,
+	// before the odds are worked out.
+	if h.deck.Remaining() == 0 {
+		h.deck.Shuffle()
+	}
+	pot := h.calculatePayout(guess)
+	from, to = h.CurrentCard, h.deck.Draw()
+	switch guess {
+	case "higher":
+		won = to.Rank > from.Rank
+	case "lower":
+		won = to.Rank < from.Rank
+	case "tie":
+		won = to.Rank == from.Rank
+	}
+
+	h.CurrentCard = to
+	if !won {
+		h.Pot = 0
+		h.Status = hiloLost
+		return from, to, false
+	}
+	h.Pot = pot
+	h.Streak++
+	return from, to, true
+}
+
+// cashOut ends the round, to be paid its pot. It needs a winning guess
+// first.
+func (h *hiloRound) cashOut() error {
+	if h.Streak == 0 {
+		return errHiLoNothingWon
+	}
+	h.Status = hiloCashedOut
+	return nil
+}
+
+// state is what the player is shown of the round.
+func (h *hiloRound) state() models.HiLoResponse {
+	resp := models.HiLoResponse{
+		RoundID:     h.ID,
+		Status:      h.Status,
+		Bet:         h.Bet,
+		Pot:         h.Pot,
+		CurrentCard: toHiLoCard(h.CurrentCard),
+		CardsLeft:   h.deck.Remaining(),
+		Currency:    h.Currency,
+		Streak:      h.Streak,
+	}
+	if h.Status == hiloActive {
+		resp.Odds = h.odds()
+	}
+	return resp
+}
+
+func toHiLoCard(c shoe.Card) models.HiLoCard {
+	card := models.HiLoCard{Value: c.Rank, Suit: c.Suit}
+	for _, s := range models.HiLoSuits {
+		if s.Symbol == c.Suit {
+			card.Color = s.Color
+		}
+	}
+	return card
+}
+
+func insertHiLoRound(tx *sql.Tx, h *hiloRound) error {
+	card, deck, err := h.marshalCards()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO hilo_rounds (id, user_id, currency, bet, pot, streak, current_card, deck, position, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		h.ID, h.UserID, h.Currency, h.Bet, h.Pot, h.Streak, card, deck, h.deck.Position, h.Status, now, now)
+	return err
+}
+
+func saveHiLoRound(tx *sql.Tx, h *hiloRound) error {
+	card, deck, err := h.marshalCards()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE hilo_rounds
+		SET pot = ?, streak = ?, current_card = ?, deck = ?, position = ?, status = ?, updated_at = ?
+		WHERE id = ?`,
+		h.Pot, h.Streak, card, deck, h.deck.Position, h.Status, time.Now(), h.ID)
+	return err
+}
+
+// loadActiveHiLoRound reads the user's round in play and locks it until tx
+// ends. A user without one gets sql.ErrNoRows.
+func loadActiveHiLoRound(tx *sql.Tx, userID int) (*hiloRound, error) {
+	h := &hiloRound{deck: &shoe.Shoe{Table: "hilo", Decks: 1}}
+	var card, deck []byte
+	err := tx.QueryRow(`
+		SELECT id, user_id, currency, bet, pot, streak, current_card, deck, position, status
+		FROM hilo_rounds
+		WHERE user_id = ? AND status = ?
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE`, userID, hiloActive).Scan(&h.ID, &h.UserID, &h.Currency, &h.Bet, &h.Pot, &h.Streak, &card, &deck,
+		&h.deck.Position, &h.Status)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(card, &h.CurrentCard); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(deck, &h.deck.Cards); err != nil {
+		return nil, err
+	}
+	h.deck.InPlay = []shoe.Card{h.CurrentCard}
+	return h, nil
+}
+
+func (h *hiloRound) marshalCards() (card, deck []byte, err error) {
+	if card, err = json.Marshal(h.CurrentCard); err != nil {
+		return
+	}
+	deck, err = json.Marshal(h.deck.Cards)
+	return
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"casino-hub/backend/money"
+	"casino-hub/backend/shoe"
+	"testing"
+)
+
+// stackedHiLoRound is a round showing current, with ranks left in the deck
+// in the order they will be drawn.
+func stackedHiLoRound(bet money.Amount, current int, ranks ...int) *hiloRound {
+	cards := make([]shoe.Card, len(ranks))
+	for i, r := range ranks {
+		cards[i] = shoe.Card{Rank: r, Suit: "♠"}
+	}
+	return &hiloRound{
+		Bet:         bet,
+		Pot:         bet,
+		Status:      hiloActive,
+		CurrentCard: shoe.Card{Rank: current, Suit: "♥"},
+		deck:        &shoe.Shoe{Table: "hilo", Decks: 1, Cards: cards},
+	}
+}
+
+func TestHiLoPotLadder(t *testing.T) {
+	h := stackedHiLoRound(1000, 7, 9, 3, 7, 13)
+	steps := []struct {
+		guess  string
+		won    bool
+		pot    money.Amount
+		status string
+	}{
+		// 2 of 4 cards beat a 7: 4/2 × 97%.
+		{"higher", true, 1940, hiloActive},
+		// 2 of 3 are below a 9: 3/2 × 97%, rounded half to even.
+		{"lower", true, 2823, hiloActive},
+		// No 3 is left, and the 7 loses the pot.
+		{"tie", false, 0, hiloLost},
+	}
+	for i, s := range steps {
+		_, _, won := h.guess(s.guess)
+		if won != s.won || h.Pot != s.pot || h.Status != s.status {
+			t.Fatalf("guess %d (%s): won = %v, pot = %s, status = %s; want %v, %s, %s",
+				i+1, s.guess, won, h.Pot, h.Status, s.won, s.pot, s.status)
+		}
+	}
+	if h.Streak != 2 {
+		t.Errorf("streak = %d, want 2", h.Streak)
+	}
+}
+
+func TestHiLoCalculatePayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		ranks   []int
+		guess   string
+		want    money.Amount
+	}{
+		{"even odds", 7, []int{2, 13}, "higher", 1940},
+		{"long shot", 13, []int{1, 2, 3, 13}, "tie", 3880},
+		// 34 of 35 cards beat an ace: 35/34 × 97% is under the stake.
+		{"near certainty pays less than the stake", 1, append(repeat(13, 34), 1), "higher", 999},
+		{"impossible guess", 1, []int{2, 3}, "lower", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := stackedHiLoRound(1000, tt.current, tt.ranks...)
+			if got := h.calculatePayout(tt.guess); got != tt.want {
+				t.Errorf("calculatePayout(%s) = %s, want %s", tt.guess, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHiLoCashOut(t *testing.T) {
+	h := stackedHiLoRound(1000, 7, 9, 3)
+	if err := h.cashOut(); err != errHiLoNothingWon || h.Status != hiloActive {
+		t.Fatalf("cash out before a win: err = %v, status = %s", err, h.Status)
+	}
+	h.guess("higher")
+	if err := h.cashOut(); err != nil || h.Status != hiloCashedOut {
+		t.Errorf("cash out after a win: err = %v, status = %s", err, h.Status)
+	}
+}
+
+func TestHiLoSpentDeckKeepsTheCardShowing(t *testing.T) {
+	h := stackedHiLoRound(1000, 7)
+	h.deck.InPlay = []shoe.Card{h.CurrentCard}
+	h.guess("higher")
+	if h.deck.Remaining() != 50 {
+		t.Errorf("%d cards left after reshuffling and drawing, want 50", h.deck.Remaining())
+	}
+	for _, c := range h.deck.Cards {
+		if c == (shoe.Card{Rank: 7, Suit: "♥"}) {
+			t.Fatal("the card showing was shuffled back into the deck")
+		}
+	}
+}
+
+func repeat(rank, n int) []int {
+	ranks := make([]int, n)
+	for i := range ranks {
+		ranks[i] = rank
+	}
+	return ranks
+}
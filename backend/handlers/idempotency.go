@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"casino-hub/backend/database"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyTTL is how long a stored response is replayed for. It can be
+// changed with IDEMPOTENCY_KEY_TTL (a Go duration such as "12h").
+func IdempotencyTTL() time.Duration {
+	if v := os.Getenv("IDEMPOTENCY_KEY_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Println("Invalid IDEMPOTENCY_KEY_TTL, using default:", v)
+	}
+	return defaultIdempotencyTTL
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware makes state-changing requests safe to retry. When a
+// request carries an Idempotency-Key header, the first response for that key
+// is stored per user and replayed for every repeat until the key expires, so
+// the handler never runs twice. It must run after AuthMiddleWare.
+func IdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, ok := GetUserID(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > 255 {
+			http.Error(w, "Idempotency-Key too long", http.StatusBadRequest)
+			return
+		}
+
+		claimed, err := claimIdempotencyKey(userID, key, r)
+		if err != nil {
+			log.Println("Idempotency error:", err)
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			replayIdempotentResponse(w, r, userID, key)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// Server errors are not stored so the client can retry with the same key.
+		if rec.status == 0 || rec.status >= 500 {
+			database.DB.Exec("DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ?", userID, key)
+			return
+		}
+		_, err = database.DB.Exec(
+			"UPDATE idempotency_keys SET status_code = ?, content_type = ?, body = ? WHERE user_id = ? AND idem_key = ?",
+			rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes(), userID, key,
+		)
+		if err != nil {
+			log.Println("Idempotency store error:", err)
+		}
+	})
+}
+
+// claimIdempotencyKey reserves the key for this request. It returns false
+// when an unexpired request with the same key already exists.
+func claimIdempotencyKey(userID int, key string, r *http.Request) (bool, error) {
+	_, err := database.DB.Exec("DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ? AND expires_at < NOW()", userID, key)
+	if err != nil {
+		return false, err
+	}
+	res, err := database.DB.Exec(
+		"INSERT IGNORE INTO idempotency_keys (user_id, idem_key, method, path, expires_at) VALUES (?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? SECOND))",
+		userID, key, r.Method, r.URL.Path, int64(IdempotencyTTL().Seconds()),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, userID int, key string) {
+	var method, path string
+	var status sql.NullInt64
+	var contentType sql.NullString
+	var body []byte
+	err := database.DB.QueryRow(
+		"SELECT method, path, status_code, content_type, body FROM idempotency_keys WHERE user_id = ? AND idem_key = ?",
+		userID, key,
+	).Scan(&method, &path, &status, &contentType, &body)
+	if err != nil {
+		log.Println("Idempotency replay error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if method != r.Method || path != r.URL.Path {
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+		return
+	}
+	if !status.Valid {
+		http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+		return
+	}
+
+	if contentType.Valid && contentType.String != "" {
+		w.Header().Set("Content-Type", contentType.String)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(status.Int64))
+	w.Write(body)
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
-	"casino-hub/backend/database"
+	"casino-hub/backend/jackpot"
+	"casino-hub/backend/keno"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"net/http"
-	"time"
 )
 
 func PlayKeno(w http.ResponseWriter, r *http.Request){
@@ -23,93 +27,96 @@ func PlayKeno(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	if len(req.SelectedNumbers) == 0 || len(req.SelectedNumbers) > 10 {
-		http.Error(w, "Select 1-10 numbers", http.StatusBadRequest)
+	if req.Bet <= 0 {
+		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
 		return
 	}
-
-	var balance int 
-	err := database.DB.QueryRow("SELECT balance FROm users WHERE id = ?", userID).Scan(&balance)
-	if err != nil{
-		http.Error(w, "Could not fetch balance", http.StatusInternalServerError)
-		return
-	}
-	if balance < req.Bet {
-		http.Error(w, "Insufficient balance", http.StatusBadRequest)
-		return
-	}
-
-	_, err = database.DB.Exec("UPDATE users SET balance = balance - ? WHERE id=?", req.Bet, userID)
-	if err != nil {
-		http.Error(w, "Could not deduct bet", http.StatusInternalServerError)
+	picks, ok := kenoPicks(w, userID, req.SelectedNumbers, req.QuickPick, req.SetID)
+	if !ok {
 		return
 	}
 
-	totalNumbers := 80
-	available := make([]int, totalNumbers)
-	for i := 0; i < totalNumbers; i++ {
-		available[i] = i + 1
-	}
-	rand.Seed(time.Now().UnixNano())
-	drawn := make([]int, 0, 20)
-	for i := 0; i< 20; i++ {
-		idx := rand.Intn(len(available))
-		drawn = append(drawn, available[idx])
-		available = append(available[:idx], available[idx+1:]...)
-	}
-
-	hits := 0
-	for _, num := range req.SelectedNumbers {
-		for _, d := range drawn {
-			if num == d{
-				hits++
-				break
-			}
+	var drawn []int
+	var hits int
+	var payout money.Amount
+	var jackpotWon bool
+	var pool money.Amount
+	roundID := wallet.NewRoundID()
+	settlement, ok := settleBet(w, wallet.Bet{UserID: userID, Game: "Keno", Currency: req.Currency, RoundID: roundID, Stake: req.Bet}, func(tx *sql.Tx) (money.Amount, error) {
+		var err error
+		if pool, err = keno.Jackpot.ContributeTx(tx, req.Currency, req.Bet); err != nil {
+			return 0, err
 		}
-	}
-
-	payout := 0
-	if table, ok := models.PayoutTable[len(req.SelectedNumbers)]; ok {
-		if hits < len(table){
-			payout = req.Bet * table[hits]
+		drawn, hits, payout, jackpotWon = drawKeno(picks, req.Bet)
+		if jackpotWon {
+			won, err := keno.Jackpot.AwardTx(tx, userID, req.Currency, roundID)
+			if err != nil {
+				return 0, err
+			}
+			payout += won
+			pool = keno.Jackpot.SeedAmount(req.Currency)
 		}
-	}
-
-	jackpotWon := false 
-	if len(req.SelectedNumbers) == 10 && hits == 10 {
-		payout += models.JackpotBase
-		models.JackpotBase = 50000
-		jackpotWon = true
-	}
-
-	_, err = database.DB.Exec("UPDATE users SET balance = balance + ? WHERE id=?", payout, userID)
-	if err != nil {
-		http.Error(w, "Could not update balance", http.StatusInternalServerError)
-		return
-	}
-
-	err = database.DB.QueryRow("SELECT balance FROM users WHERE id=?", userID).Scan(&balance)
-	if err != nil {
-		http.Error(w, "Could not fetch balance", http.StatusInternalServerError)
+		return payout, nil
+	})
+	if !ok {
 		return
 	}
+	mystery, balance := dropMysteryJackpot(userID, "Keno", settlement)
 
 	if err := RecordGamePlay(userID, "Keno"); err != nil {
 		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-		return
 	}
 
 	resp := models.KenoResponse{
-		DrawnNumbers: drawn,
-		Hits:         hits,
-		Payout:       payout,
-		JackpotWon:   jackpotWon,
-		NewBalance:   balance,
-		Message:      "Keno round completed",
+		SelectedNumbers: picks,
+		DrawnNumbers:    drawn,
+		Hits:            hits,
+		Payout:          payout,
+		JackpotWon:      jackpotWon,
+		Jackpot:         pool,
+		NewBalance:      balance,
+		Currency:        settlement.Currency,
+		RealityCheckDue: settlement.RealityCheckDue,
+		Message:         "Keno round completed",
+		MysteryJackpots: mystery,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 
-}
\ No newline at end of file
+}
+
+func drawKeno(selected []int, bet money.Amount) ([]int, int, money.Amount, bool) {
+	drawn := keno.Draw()
+	hits := keno.CountHits(selected, drawn)
+	// The jackpot itself is paid by the caller.
+	return drawn, hits, keno.Payout(bet, len(selected), hits), keno.IsJackpot(len(selected), hits)
+}
+
+// GetKenoJackpot godoc
+// @Summary Get the Keno jackpot
+// @Description Returns the Keno progressive jackpot in a currency and its last winner
+// @Tags keno
+// @Produce json
+// @Param currency query string false "Currency code (default GC)"
+// @Success 200 {object} models.JackpotInfo
+// @Failure 400 {string} string "Unknown currency"
+// @Router /api/v1/keno/jackpot [get]
+func GetKenoJackpot(w http.ResponseWriter, r *http.Request) {
+	writeJackpotInfo(w, keno.Jackpot, r.URL.Query().Get("currency"))
+}
+
+func writeJackpotInfo(w http.ResponseWriter, pool jackpot.Pool, currency string) {
+	info, err := pool.Info(currency)
+	if errors.Is(err, wallet.ErrUnknownCurrency) {
+		http.Error(w, "Unknown currency", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println("Jackpot error:", err)
+		http.Error(w, "Failed to fetch jackpot", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/keno"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultKenoListLimit = 20
+	maxKenoListLimit     = 100
+)
+
+// BuyKenoTickets godoc
+// @Summary Buy live Keno tickets
+// @Description Buys the same ticket for the next live draw or several consecutive ones. The currency limits apply to the bet on each draw. The whole stake is taken now; each ticket is paid when its draw runs.
+// @Tags keno
+// @Accept json
+// @Produce json
+// @Param request body models.KenoTicketRequest true "Ticket"
+// @Success 200 {object} models.KenoTicketResponse
+// @Failure 400 {string} string "Invalid request"
+// @Failure 409 {string} string "Draws are not open"
+// @Router /api/v1/keno/tickets [post]
+func BuyKenoTickets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.KenoTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Draws == 0 {
+		req.Draws = 1
+	}
+	if req.Draws < 0 || req.Draws > keno.MaxDraws {
+		http.Error(w, fmt.Sprintf("Tickets can be bought for 1-%d draws", keno.MaxDraws), http.StatusBadRequest)
+		return
+	}
+	if req.Bet <= 0 {
+		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
+		return
+	}
+	currency, err := wallet.LookupCurrency(req.Currency)
+	if err != nil {
+		http.Error(w, "Unknown currency", http.StatusBadRequest)
+		return
+	}
+	// The limits apply to each draw's ticket, not to the total stake.
+	if err := currency.CheckStake(req.Bet); err != nil {
+		writeSettlementError(w, err)
+		return
+	}
+	picks, ok := kenoPicks(w, userID, req.SelectedNumbers, req.QuickPick, req.SetID)
+	if !ok {
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	draws, err := keno.LockDrawsTx(tx, time.Now(), req.Draws)
+	if errors.Is(err, keno.ErrNoOpenDraws) {
+		http.Error(w, "Draws are not open for tickets", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Println("Keno draw error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var tickets []models.KenoTicket
+	roundID := wallet.NewRoundID()
+	stake := req.Bet.Times(int64(req.Draws))
+	settlement, ok := settleBetTx(w, tx, wallet.Bet{UserID: userID, Game: "Keno", Currency: currency.Code, RoundID: roundID, Stake: stake, StakeChecked: true}, func(tx *sql.Tx) (money.Amount, error) {
+		if _, err := keno.Jackpot.ContributeTx(tx, currency.Code, stake); err != nil {
+			return 0, err
+		}
+		var err error
+		tickets, err = keno.BuyTicketsTx(tx, userID, currency.Code, roundID, picks, req.Bet, draws)
+		return 0, err
+	})
+	if !ok {
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	mystery, balance := dropMysteryJackpot(userID, "Keno", settlement)
+
+	if err := RecordGamePlay(userID, "Keno"); err != nil {
+		fmt.Println("RecordGamePlay error:", err)
+	}
+
+	resp := models.KenoTicketResponse{
+		Tickets:         tickets,
+		TotalStake:      stake,
+		NewBalance:      balance,
+		Currency:        settlement.Currency,
+		MysteryJackpots: mystery,
+		RealityCheckDue: settlement.RealityCheckDue,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetKenoTickets godoc
+// @Summary List live Keno tickets
+// @Description Returns the logged-in user's live Keno tickets, newest first, with their results once drawn
+// @Tags keno
+// @Produce json
+// @Param status query string false "pending, settled or void"
+// @Param limit query int false "Number of tickets (default 20, max 100)"
+// @Success 200 {array} models.KenoTicket
+// @Failure 400 {string} string "Invalid query"
+// @Router /api/v1/keno/tickets [get]
+func GetKenoTickets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != keno.TicketPending && status != keno.TicketSettled && status != keno.TicketVoid {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	limit, ok := kenoListLimit(w, r)
+	if !ok {
+		return
+	}
+
+	tickets, err := keno.Tickets(userID, status, limit)
+	if err != nil {
+		log.Println("GetKenoTickets error:", err)
+		http.Error(w, "Failed to fetch tickets", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tickets)
+}
+
+// GetKenoDraws godoc
+// @Summary List live Keno draws
+// @Description Returns the last live Keno draws and their numbers, newest first
+// @Tags keno
+// @Produce json
+// @Param limit query int false "Number of draws (default 20, max 100)"
+// @Success 200 {array} models.KenoDraw
+// @Failure 400 {string} string "Invalid limit"
+// @Router /api/v1/keno/draws [get]
+func GetKenoDraws(w http.ResponseWriter, r *http.Request) {
+	limit, ok := kenoListLimit(w, r)
+	if !ok {
+		return
+	}
+	draws, err := keno.History(limit)
+	if err != nil {
+		log.Println("GetKenoDraws error:", err)
+		http.Error(w, "Failed to fetch draws", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(draws)
+}
+
+// GetNextKenoDraw godoc
+// @Summary Get the next live Keno draw
+// @Description Returns the next live draw tickets can be bought for
+// @Tags keno
+// @Produce json
+// @Success 200 {object} models.KenoDraw
+// @Router /api/v1/keno/draws/next [get]
+func GetNextKenoDraw(w http.ResponseWriter, r *http.Request) {
+	draw, err := keno.NextDraw(time.Now())
+	if err != nil {
+		log.Println("GetNextKenoDraw error:", err)
+		http.Error(w, "Failed to fetch draw", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(draw)
+}
+
+func kenoListLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultKenoListLimit, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > maxKenoListLimit {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
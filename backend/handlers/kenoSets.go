@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"casino-hub/backend/keno"
+	"casino-hub/backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// kenoPicks resolves the numbers of a ticket from the numbers the player
+// picked, a quick pick or a saved set, whichever one was given. It writes
+// the error response itself when ok is false.
+func kenoPicks(w http.ResponseWriter, userID int, selected []int, quickPick int, setID int64) ([]int, bool) {
+	sources := 0
+	for _, given := range []bool{len(selected) > 0, quickPick != 0, setID != 0} {
+		if given {
+			sources++
+		}
+	}
+	if sources != 1 {
+		http.Error(w, "Give exactly one of selectedNumbers, quickPick or setId", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var picks []int
+	var err error
+	switch {
+	case quickPick != 0:
+		picks, err = keno.QuickPick(quickPick)
+	case setID != 0:
+		var set models.KenoSet
+		set, err = keno.Set(userID, setID)
+		picks = set.Numbers
+	default:
+		picks, err = selected, keno.ValidatePicks(selected)
+	}
+	if err != nil {
+		writeKenoError(w, err)
+		return nil, false
+	}
+	return picks, true
+}
+
+func writeKenoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, keno.ErrPickCount):
+		http.Error(w, fmt.Sprintf("Select 1-%d numbers", keno.MaxPicks), http.StatusBadRequest)
+	case errors.Is(err, keno.ErrPickRange):
+		http.Error(w, fmt.Sprintf("Numbers must be between 1 and %d", keno.Numbers), http.StatusBadRequest)
+	case errors.Is(err, keno.ErrDuplicatePick):
+		http.Error(w, "Each number can only be picked once", http.StatusBadRequest)
+	case errors.Is(err, keno.ErrSetName):
+		http.Error(w, "A number set needs a name of at most 64 characters", http.StatusBadRequest)
+	case errors.Is(err, keno.ErrSetNameTaken):
+		http.Error(w, "You already have a number set with that name", http.StatusConflict)
+	case errors.Is(err, keno.ErrTooManySets):
+		http.Error(w, fmt.Sprintf("You can save at most %d number sets", keno.MaxSets), http.StatusConflict)
+	case errors.Is(err, keno.ErrSetNotFound):
+		http.Error(w, "Number set not found", http.StatusNotFound)
+	default:
+		log.Println("Keno error:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+	}
+}
+
+// GetKenoSets godoc
+// @Summary List saved Keno number sets
+// @Tags keno
+// @Produce json
+// @Success 200 {array} models.KenoSet
+// @Router /api/v1/keno/sets [get]
+func GetKenoSets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sets, err := keno.Sets(userID)
+	if err != nil {
+		writeKenoError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sets)
+}
+
+// CreateKenoSet godoc
+// @Summary Save a Keno number set
+// @Tags keno
+// @Accept json
+// @Produce json
+// @Param request body models.KenoSetRequest true "Number set"
+// @Success 201 {object} models.KenoSet
+// @Failure 400 {string} string "Invalid number set"
+// @Failure 409 {string} string "Name taken or too many sets"
+// @Router /api/v1/keno/sets [post]
+func CreateKenoSet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req models.KenoSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	set, err := keno.CreateSet(userID, req.Name, req.Numbers)
+	if err != nil {
+		writeKenoError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(set)
+}
+
+// GetKenoSet godoc
+// @Summary Get a Keno number set
+// @Tags keno
+// @Produce json
+// @Param id path int true "Set ID"
+// @Success 200 {object} models.KenoSet
+// @Failure 404 {string} string "Number set not found"
+// @Router /api/v1/keno/sets/{id} [get]
+func GetKenoSet(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := kenoSetRequest(w, r)
+	if !ok {
+		return
+	}
+	set, err := keno.Set(userID, id)
+	if err != nil {
+		writeKenoError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+// UpdateKenoSet godoc
+// @Summary Update a Keno number set
+// @Tags keno
+// @Accept json
+// @Produce json
+// @Param id path int true "Set ID"
+// @Param request body models.KenoSetRequest true "Number set"
+// @Success 200 {object} models.KenoSet
+// @Failure 400 {string} string "Invalid number set"
+// @Failure 404 {string} string "Number set not found"
+// @Router /api/v1/keno/sets/{id} [put]
+func UpdateKenoSet(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := kenoSetRequest(w, r)
+	if !ok {
+		return
+	}
+	var req models.KenoSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	set, err := keno.UpdateSet(userID, id, req.Name, req.Numbers)
+	if err != nil {
+		writeKenoError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+// DeleteKenoSet godoc
+// @Summary Delete a Keno number set
+// @Tags keno
+// @Param id path int true "Set ID"
+// @Success 204
+// @Failure 404 {string} string "Number set not found"
+// @Router /api/v1/keno/sets/{id} [delete]
+func DeleteKenoSet(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := kenoSetRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := keno.DeleteSet(userID, id); err != nil {
+		writeKenoError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// kenoSetRequest reads the user and the set ID from the path.
+func kenoSetRequest(w http.ResponseWriter, r *http.Request) (int, int64, bool) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid set ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
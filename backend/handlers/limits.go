@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"casino-hub/backend/limits"
+	"casino-hub/backend/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// GetLimits godoc
+// @Summary Get responsible gaming limits
+// @Description Returns the logged-in user's loss, wager and session limits with what is used up and any pending changes
+// @Tags limits
+// @Produce json
+// @Success 200 {object} models.Limits
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/limits [get]
+func GetLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	writeLimits(w, userID)
+}
+
+// UpdateLimits godoc
+// @Summary Set responsible gaming limits
+// @Description Lowering a limit applies immediately; raising or removing one applies after the cooling-off period
+// @Tags limits
+// @Accept json
+// @Produce json
+// @Param request body models.LimitsUpdate true "Limits to change"
+// @Success 200 {object} models.Limits
+// @Failure 400 {string} string "Invalid request"
+// @Router /api/v1/limits [put]
+func UpdateLimits(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.LimitsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := limits.Update(userID, req); err != nil {
+		if errors.Is(err, limits.ErrInvalidLimit) {
+			writeError(w, http.StatusBadRequest, "invalid_limit", err.Error(), nil)
+			return
+		}
+		log.Println("UpdateLimits error:", err)
+		http.Error(w, "Failed to update limits", http.StatusInternalServerError)
+		return
+	}
+	writeLimits(w, userID)
+}
+
+func writeLimits(w http.ResponseWriter, userID int) {
+	res, err := limits.List(userID)
+	if err != nil {
+		log.Println("Limits error:", err)
+		http.Error(w, "Failed to fetch limits", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"casino-hub/backend/jackpot"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultMysteryWinners = 10
+	maxMysteryWinners     = 100
+)
+
+// dropMysteryJackpot runs a settled wager through the mystery jackpot and
+// returns the tiers it won and the player's balance afterwards. The wager
+// stands whatever happens here, so errors are only logged.
+func dropMysteryJackpot(userID int, game string, s betResult) ([]models.MysteryJackpotWin, money.Amount) {
+	wins, err := jackpot.Drop(userID, game, s.Currency, s.RoundID, s.Stake)
+	if err != nil {
+		log.Println("Mystery jackpot error:", err)
+		return nil, s.Balance
+	}
+	if len(wins) == 0 {
+		return nil, s.Balance
+	}
+	balance, _, err := wallet.Balances(userID, s.Currency)
+	if err != nil {
+		log.Println("Mystery jackpot balance error:", err)
+		return wins, s.Balance
+	}
+	return wins, balance
+}
+
+// GetMysteryJackpots godoc
+// @Summary Get the mystery jackpots
+// @Description Returns the Mini, Minor, Major and Grand mystery jackpots shared by Slot, Progressive Slot, Keno and Roulette in a currency, and their last winners
+// @Tags jackpots
+// @Produce json
+// @Param currency query string false "Currency code (default GC)"
+// @Param limit query int false "Number of winners (default 10, max 100)"
+// @Success 200 {object} models.MysteryJackpots
+// @Failure 400 {string} string "Invalid query"
+// @Router /api/v1/jackpots/mystery [get]
+func GetMysteryJackpots(w http.ResponseWriter, r *http.Request) {
+	limit := defaultMysteryWinners
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxMysteryWinners {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	info, err := jackpot.Mystery(r.URL.Query().Get("currency"), limit)
+	if errors.Is(err, wallet.ErrUnknownCurrency) {
+		http.Error(w, "Unknown currency", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println("Mystery jackpot error:", err)
+		http.Error(w, "Failed to fetch jackpots", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
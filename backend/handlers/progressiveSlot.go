@@ -1,8 +1,11 @@
 package handlers
 
 import (
-	"casino-hub/backend/database"
+	"casino-hub/backend/jackpot"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -10,6 +13,24 @@ import (
 	"time"
 )
 
+// progressiveJackpot is won by a spin showing jackpotSymbolCount or more
+// Crowns, paid on top of the line win. It is configured with
+// PROGRESSIVE_JACKPOT_SEED, PROGRESSIVE_JACKPOT_SEED_SC and
+// PROGRESSIVE_JACKPOT_CONTRIBUTION. Sweeps coins are redeemable and capped
+// at 500 a spin, so their pool starts from a seed in line with that.
+var progressiveJackpot = jackpot.Pool{
+	Name:         "progressive-slot",
+	Env:          "PROGRESSIVE_JACKPOT",
+	Seed:         money.Coins(100000),
+	Seeds:        map[string]money.Amount{wallet.SweepsCoins: money.Coins(1000)},
+	Contribution: 100,
+}
+
+const (
+	jackpotSymbolID    = 6
+	jackpotSymbolCount = 3
+)
+
 func ProgressiveSlotHandler(w http.ResponseWriter, r *http.Request){
 	userID, ok := GetUserID(r.Context())
 	if !ok || userID <=0 {
@@ -23,24 +44,61 @@ func ProgressiveSlotHandler(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	var balance int 
-	if err := database.DB.QueryRow("SELECT balance FROM users WHERE id = ?", userID).Scan(&balance); err != nil {
+	if req.Bet <= 0 {
 		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
 		return
-	} 
+	}
 
-	if req.Bet <= 0 || req.Bet > balance {
-		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
+	var reelResults []int
+	var winAmount money.Amount
+	var winType string
+	var jackpotWon bool
+	var pool money.Amount
+	roundID := wallet.NewRoundID()
+	settlement, ok := settleBet(w, wallet.Bet{UserID: userID, Game: "Progressive Slot", Currency: req.Currency, RoundID: roundID, Stake: req.Bet}, func(tx *sql.Tx) (money.Amount, error) {
+		var err error
+		if pool, err = progressiveJackpot.ContributeTx(tx, req.Currency, req.Bet); err != nil {
+			return 0, err
+		}
+		reelResults, winAmount, winType = spinProgressiveReels(req.Bet)
+		if jackpotWon = isProgressiveJackpot(reelResults); jackpotWon {
+			won, err := progressiveJackpot.AwardTx(tx, userID, req.Currency, roundID)
+			if err != nil {
+				return 0, err
+			}
+			winAmount += won
+			winType = "jackpot"
+			pool = progressiveJackpot.SeedAmount(req.Currency)
+		}
+		return winAmount, nil
+	})
+	if !ok {
 		return
 	}
+	mystery, balance := dropMysteryJackpot(userID, "Progressive Slot", settlement)
 
-	balance -= req.Bet
-	_, err := database.DB.Exec("UPDATE users SET balance = ? WHERE id = ?", balance, userID)
-	if err != nil {
-		http.Error(w, "Could not update balance", http.StatusInternalServerError)
-		return
+	if err := RecordGamePlay(userID, "Progressive Slot"); err != nil {
+		fmt.Println("RecordGamePlay error:", err)
 	}
+	
+
+	resp := models.SpinSlotResponse {
+		ReelResults:     reelResults,
+		WinAmount:       winAmount,
+		NewBalance:      balance,
+		Currency:        settlement.Currency,
+		RealityCheckDue: settlement.RealityCheckDue,
+		WinType:         winType,
+		JackpotWon:      jackpotWon,
+		Jackpot:         pool,
+		MysteryJackpots: mystery,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
 
+func spinProgressiveReels(bet money.Amount) ([]int, money.Amount, string) {
 	rand.Seed(time.Now().UnixNano())
 
 	reelResults := make([]int, 5)
@@ -61,7 +119,7 @@ func ProgressiveSlotHandler(w http.ResponseWriter, r *http.Request){
 		symbolCounts[models.SYMBOLS[idx].ID]++
 	}
 
-	winAmount := 0
+	var winAmount money.Amount
 	winType := "normal"
 
 	for id, count := range symbolCounts {
@@ -83,48 +141,43 @@ func ProgressiveSlotHandler(w http.ResponseWriter, r *http.Request){
 			if symbol == nil {
 				continue
 			}
-			baseWin := req.Bet * symbol.Multiplier
-			multiplier := 1
+			baseWin := bet.Times(int64(symbol.Multiplier))
+			multiplier := int64(1)
 			if count == 4 {
 				multiplier = 3
 			} else if count == 5 {
 				multiplier = 10
 			}
-			winAmount += baseWin * multiplier
+			winAmount += baseWin.Times(multiplier)
 		}
 	}
 
-	if winAmount > 0 {
-		if winAmount > req.Bet*20{
-			winType = "big"
-		}
-		if rand.Float64() < 0.001 {
-			winAmount += 500000
-			winType = "jackpot"
-		}
+	if winAmount > bet.Times(20){
+		winType = "big"
 	}
 
-	balance += winAmount
-	_, err = database.DB.Exec("UPDATE users SET balance = ? WHERE id = ?", balance, userID)
-	if err != nil {
-		http.Error(w, "Could not update balance", http.StatusInternalServerError)
-		return
-	}
+	return reelResults, winAmount, winType
+}
 
-	if err := RecordGamePlay(userID, "Progressive Slot"); err != nil {
-		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-		return
-	}
-	
-
-	resp := models.SpinSlotResponse {
-		ReelResults: reelResults,
-		WinAmount:   winAmount,
-		NewBalance:  balance,
-		WinType:     winType,
+func isProgressiveJackpot(reelResults []int) bool {
+	count := 0
+	for _, idx := range reelResults {
+		if models.SYMBOLS[idx].ID == jackpotSymbolID {
+			count++
+		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	return count >= jackpotSymbolCount
+}
+
+// GetProgressiveJackpot godoc
+// @Summary Get the Progressive Slot jackpot
+// @Description Returns the Progressive Slot jackpot in a currency and its last winner
+// @Tags progressiveSlot
+// @Produce json
+// @Param currency query string false "Currency code (default GC)"
+// @Success 200 {object} models.JackpotInfo
+// @Failure 400 {string} string "Unknown currency"
+// @Router /api/v1/progressiveSlot/jackpot [get]
+func GetProgressiveJackpot(w http.ResponseWriter, r *http.Request) {
+	writeJackpotInfo(w, progressiveJackpot, r.URL.Query().Get("currency"))
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"casino-hub/backend/wallet"
+	"testing"
+)
+
+func TestIsProgressiveJackpot(t *testing.T) {
+	// Reel positions index models.SYMBOLS; 5 is the Crown.
+	tests := []struct {
+		name  string
+		reels []int
+		want  bool
+	}{
+		{"three crowns", []int{5, 5, 5, 0, 1}, true},
+		{"crowns anywhere", []int{5, 0, 5, 1, 5}, true},
+		{"five crowns", []int{5, 5, 5, 5, 5}, true},
+		{"two crowns", []int{5, 5, 0, 0, 0}, false},
+		{"three of something else", []int{4, 4, 4, 0, 1}, false},
+		{"no crowns", []int{0, 1, 2, 3, 4}, false},
+	}
+	for _, tt := range tests {
+		if got := isProgressiveJackpot(tt.reels); got != tt.want {
+			t.Errorf("%s: isProgressiveJackpot(%v) = %v, want %v", tt.name, tt.reels, got, tt.want)
+		}
+	}
+}
+
+func TestProgressiveJackpotSeeds(t *testing.T) {
+	// A redeemable pool must not start out worth more than a few maximum
+	// bets.
+	for _, code := range wallet.CurrencyCodes {
+		c := wallet.Currencies[code]
+		seed := progressiveJackpot.SeedAmount(code)
+		if seed <= 0 {
+			t.Errorf("%s seed is %s", code, seed)
+		}
+		if c.Redeemable && (c.MaxBet == 0 || seed > c.MaxBet.Times(10)) {
+			t.Errorf("%s seed of %s against a maximum bet of %s", code, seed, c.MaxBet)
+		}
+	}
+}
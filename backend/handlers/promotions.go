@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"casino-hub/backend/database"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
 	"database/sql"
 	"encoding/json"
 	"math/rand"
@@ -28,7 +30,7 @@ func toJSON(data []string) string {
 
 type UserProfile struct {
 	ID            int64     `json:"id"`
-	Balance       float64   `json:"balance"`
+	Balance       money.Amount `json:"balance"`
 	LastCashClaim time.Time `json:"lastCashClaim"`
 	LastWheelSpin time.Time `json:"lastWheelSpin"`
 	FreeGames     []string  `json:"freeGames"`
@@ -41,7 +43,7 @@ func ClaimDailyCash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var lastClaim time.Time
-	var balance float64
+	var balance money.Amount
 
 	err := database.DB.QueryRow(`
 		SELECT last_cash_claim, balance
@@ -58,20 +60,29 @@ func ClaimDailyCash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newBalance := balance + 100 
 	now := time.Now()
-	_, err = database.DB.Exec(`
-		UPDATE users
-		SET balance = ?, last_cash_claim = ?
-		WHERE id = ?
-	`, newBalance, now, userID)
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	t, err := wallet.GrantBonusTx(tx, userID, wallet.GoldCoins, money.Coins(100), wallet.ReasonDailyCash)
+	if err == nil {
+		_, err = tx.Exec("UPDATE users SET last_cash_claim = ? WHERE id = ?", now, userID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
 
 	resp := map[string]interface{}{
-		"balance":       newBalance,
+		"balance":       balance,
+		"bonusBalance":  t.BalanceAfter,
 		"lastCashClaim": now.Format(time.RFC3339),
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -86,7 +97,7 @@ func SpinWheel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var lastSpin time.Time
-	var balance float64
+	var balance money.Amount
 	var freeGamesSQL sql.NullString
 	err := database.DB.QueryRow(`
 		SELECT last_wheel_spin, balance, free_games
@@ -132,10 +143,11 @@ func SpinWheel(w http.ResponseWriter, r *http.Request) {
 	var message string
 	newBalance := balance
 	newFreeGames := parseFreeGames(freeGamesJSON)
+	var credit money.Amount
 
 	switch winning.Type {
 	case "money":
-		newBalance += float64(winning.Value)
+		credit = money.Coins(int64(winning.Value))
 		message = "You won " + winning.Text + "!"
 	case "bonus":
 		message = "Bonus spin! Spin again now!"
@@ -148,12 +160,33 @@ func SpinWheel(w http.ResponseWriter, r *http.Request) {
 		spinTime = time.Now()
 	}
 
-	_, err = database.DB.Exec(`
+	tx, err := database.DB.Begin()
+	if err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if credit > 0 {
+		if _, err := wallet.GrantBonusTx(tx, userID, wallet.GoldCoins, credit, wallet.ReasonWheel); err != nil {
+			http.Error(w, "Failed to update user", http.StatusInternalServerError)
+			return
+		}
+	}
+	var bonusBalance money.Amount
+	if err := tx.QueryRow("SELECT bonus_balance FROM users WHERE id = ?", userID).Scan(&bonusBalance); err != nil {
+		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec(`
 		UPDATE users
-		SET balance = ?, last_wheel_spin = ?, free_games = ?
+		SET last_wheel_spin = ?, free_games = ?
 		WHERE id = ?
-	`, newBalance, spinTime, toJSON(newFreeGames), userID)
-
+	`, spinTime, toJSON(newFreeGames), userID)
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		http.Error(w, "Failed to update user", http.StatusInternalServerError)
 		return
@@ -162,6 +195,7 @@ func SpinWheel(w http.ResponseWriter, r *http.Request) {
 	resp := map[string]interface{}{
 		"reward":        winning.Text,
 		"balance":       newBalance,
+		"bonusBalance":  bonusBalance,
 		"freeGames":     newFreeGames,
 		"lastWheelSpin": spinTime.Format(time.RFC3339),
 		"message":       message,
@@ -1,15 +1,14 @@
 package handlers
 
 import (
-	"casino-hub/backend/database"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
-	"strconv"
-	"time"
 )
 
 func SpinRoulette(w http.ResponseWriter, r *http.Request){
@@ -24,104 +23,80 @@ func SpinRoulette(w http.ResponseWriter, r *http.Request){
 		http.Error(w, "Invalid Request", http.StatusBadRequest)
 		return
 	}
-	if req.Stake <= 0 {
-		http.Error(w, "Invalid stake", http.StatusBadRequest)
+	wheel, ok := lookupRouletteWheel(req.Variant)
+	if !ok {
+		http.Error(w, "Invalid variant", http.StatusBadRequest)
 		return
 	}
-
-	var balance float64 
-	err := database.DB.QueryRow("SELECT balance FROM users WHERE id = ?", userID).Scan(&balance)
+	wagers, stake, err := rouletteWagers(wheel, req)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "User not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if float64(req.Stake) > balance {
-		http.Error(w, "Insufficient value", http.StatusBadRequest)
+		http.Error(w, "Invalid bet: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	balance -= float64(req.Stake)
+	var winning models.RoulettePocket
+	var prisonNumber *int
+	var payout money.Amount
+	results := make([]models.RouletteBetResult, 0, len(wagers))
+	roundID := wallet.NewRoundID()
+	settlement, ok := settleBet(w, wallet.Bet{UserID: userID, Game: "Roulette", Currency: req.Currency, RoundID: roundID, Stake: stake}, func(tx *sql.Tx) (money.Amount, error) {
+		winning = wheel.Pockets[rand.Intn(len(wheel.Pockets))]
 
-	rand.Seed(time.Now().UnixNano())
-	winning := models.POCKETS[rand.Intn(len(models.POCKETS))]
-
-	payout := evaluateRouletteBet(req.Bet, winning.N, winning.Color, req.Stake)
-	if payout > 0 {
-		balance += float64(payout + req.Stake)
-	}
+		// Bets held En Prison are settled by the next spin.
+		prison := -1
+		if wheel.EvenMoney == enPrison && wheel.isZero(winning.N) {
+			prison = wheel.Pockets[rand.Intn(len(wheel.Pockets))].N
+			prisonNumber = &prison
+		}
+		if err := recordRouletteSpin(tx, roundID, userID, wheel.Variant, winning, prisonNumber); err != nil {
+			return 0, err
+		}
 
-	_, err = database.DB.Exec("UPDATE users SET balance = ? WHERE id = ?", balance, userID)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		var returned money.Amount
+		for _, wager := range wagers {
+			res := wager.settle(wheel, winning.N, prison)
+			results = append(results, res)
+			returned += res.Payout
+		}
+		if returned > stake {
+			payout = returned - stake
+		}
+		return returned, nil
+	})
+	if !ok {
 		return
 	}
+	mystery, balance := dropMysteryJackpot(userID, "Roulette", settlement)
 
 	if err := RecordGamePlay(userID, "Roulette"); err != nil {
 		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-		return
 	}
 	
 	
 	resp := models.RouletteResponse{
-		WinningNumber: winning.N,
-		Payout:        payout,
-		NewBalance:    balance,
-		Message:       buildMessage(payout, winning.N),
+		Variant:         wheel.Variant,
+		WinningNumber:   winning.N,
+		WinningPocket:   pocketLabel(winning.N),
+		WinningColor:    winning.Color,
+		PrisonNumber:    prisonNumber,
+		Payout:          payout,
+		TotalStake:      stake,
+		Bets:            results,
+		NewBalance:      balance,
+		Currency:        settlement.Currency,
+		RealityCheckDue: settlement.RealityCheckDue,
+		Message:         buildMessage(payout, winning.N),
+		MysteryJackpots: mystery,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-func evaluateRouletteBet(bet models.RouletteBet, winningNumber int, winningColor string, stake int) int {
-	switch bet.Kind {
-	case "number":
-		val, ok := bet.Value.(float64)
-		if ok && int(val) == winningNumber {
-			return stake * 35
-		}
-	case "color":
-		val, ok := bet.Value.(string)
-		if ok && val == winningColor {
-			return stake * 1
-		} 
-	case "parity":
-		val, ok := bet.Value.(string)
-		if ok && winningNumber != 0 {
-			if (winningNumber % 2 == 0 && val == "even") || (winningNumber%2 == 1 && val == "odd"){
-				return stake * 1
-			}
-		}
-	case "dozen":
-		val, ok := bet.Value.(float64)
-		if ok && winningNumber > 0 {
-			dozen := (winningNumber-1)/12 + 1
-			if int(val) == dozen {
-				return stake * 2
-			}
-		}
-	case "column":
-		val, ok := bet.Value.(float64)
-		if ok && winningNumber > 0 {
-			col := ((winningNumber-1)%3)+1
-			if int(val) == col {
-				return stake * 2
-			}
-		}
-	}
-	return 0
-}
-
-func buildMessage(payout int, winningNumber int) string {
+func buildMessage(payout money.Amount, winningNumber int) string {
 	if payout > 0 {
-		return "You won! Number "+ strconv.Itoa(winningNumber)
+		return "You won! Number "+ pocketLabel(winningNumber)
 	}
-	return "You lost. Number "+ strconv.Itoa(winningNumber)
+	return "You lost. Number "+ pocketLabel(winningNumber)
 }
 
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	errUnknownRouletteBet = errors.New("unknown bet kind")
+	errInvalidRouletteBet = errors.New("invalid bet value")
+	errIllegalNumbers     = errors.New("the numbers are not a legal combination for this bet")
+	errInvalidStake       = errors.New("stakes must be positive")
+	errCallBetWheel       = errors.New("call bets are only offered on single-zero wheels")
+)
+
+// rouletteChip is a single chip on the layout: a stake on a set of numbers.
+type rouletteChip struct {
+	numbers []int
+	stake   money.Amount
+}
+
+// pays is the chip's payout ratio. The layout pays every bet as though 36
+// pockets were shared evenly between the numbers it covers, on every wheel;
+// the zeros are where the house edge comes from.
+func (c rouletteChip) pays() int64 {
+	return int64(36/len(c.numbers) - 1)
+}
+
+func (c rouletteChip) covers(number int) bool {
+	for _, n := range c.numbers {
+		if n == number {
+			return true
+		}
+	}
+	return false
+}
+
+// evenMoney reports whether the chip is on red/black, odd/even or
+// high/low.
+func (c rouletteChip) evenMoney() bool {
+	return len(c.numbers) == 18
+}
+
+// rouletteWager is a bet on the slip and the chips it is made of. A call bet
+// spreads its stake over several chips; every other bet is a single chip.
+type rouletteWager struct {
+	bet   models.RouletteBet
+	stake money.Amount
+	chips []rouletteChip
+}
+
+// callBetChip is one placement of a call bet, in units of its stake.
+type callBetChip struct {
+	numbers []int
+	units   int64
+}
+
+// callBets are the neighbour bets announced on the racetrack, as the chips a
+// dealer places for them.
+var callBets = map[string][]callBetChip{
+	// Voisins du zéro: the 17 numbers either side of zero.
+	"voisins": {
+		{[]int{0, 2, 3}, 2},
+		{[]int{4, 7}, 1},
+		{[]int{12, 15}, 1},
+		{[]int{18, 21}, 1},
+		{[]int{19, 22}, 1},
+		{[]int{32, 35}, 1},
+		{[]int{25, 26, 28, 29}, 2},
+	},
+	// Tiers du cylindre: the third of the wheel opposite zero.
+	"tiers": {
+		{[]int{5, 8}, 1},
+		{[]int{10, 11}, 1},
+		{[]int{13, 16}, 1},
+		{[]int{23, 24}, 1},
+		{[]int{27, 30}, 1},
+		{[]int{33, 36}, 1},
+	},
+	// Orphelins: the numbers neither of the others covers.
+	"orphelins": {
+		{[]int{1}, 1},
+		{[]int{6, 9}, 1},
+		{[]int{14, 17}, 1},
+		{[]int{17, 20}, 1},
+		{[]int{31, 34}, 1},
+	},
+}
+
+// rouletteWagers returns the bets on a slip, checking that each is valid on
+// the wheel, and their total stake.
+func rouletteWagers(wheel rouletteWheel, req models.RouletteRequest) ([]rouletteWager, money.Amount, error) {
+	bets := req.Bets
+	if len(bets) == 0 && req.Bet.Kind != "" {
+		single := req.Bet
+		single.Stake = req.Stake
+		bets = []models.RouletteBet{single}
+	}
+	if len(bets) == 0 {
+		return nil, 0, errEmptyBetSlip
+	}
+
+	wagers := make([]rouletteWager, 0, len(bets))
+	var total money.Amount
+	for i, bet := range bets {
+		if bet.Stake <= 0 {
+			return nil, 0, errInvalidStake
+		}
+		chips, err := rouletteChips(wheel, bet)
+		if err != nil {
+			return nil, 0, fmt.Errorf("bet %d (%s): %w", i+1, bet.Kind, err)
+		}
+		wagers = append(wagers, rouletteWager{bet: bet, stake: bet.Stake, chips: chips})
+		total += bet.Stake
+	}
+	return wagers, total, nil
+}
+
+// rouletteChips checks a bet against the wheel's layout and places its
+// chips.
+func rouletteChips(wheel rouletteWheel, bet models.RouletteBet) ([]rouletteChip, error) {
+	if layout, ok := callBets[bet.Kind]; ok {
+		if wheel.doubleZero() {
+			return nil, errCallBetWheel
+		}
+		var units int64
+		for _, c := range layout {
+			units += c.units
+		}
+		if bet.Stake%money.Amount(units) != 0 {
+			return nil, fmt.Errorf("the stake must split evenly into %d chips", units)
+		}
+		unit := bet.Stake / money.Amount(units)
+		chips := make([]rouletteChip, len(layout))
+		for i, c := range layout {
+			chips[i] = rouletteChip{numbers: c.numbers, stake: unit.Times(c.units)}
+		}
+		return chips, nil
+	}
+
+	numbers, err := rouletteNumbers(wheel, bet)
+	if err != nil {
+		return nil, err
+	}
+	return []rouletteChip{{numbers: numbers, stake: bet.Stake}}, nil
+}
+
+// rouletteNumbers returns the numbers a single-chip bet covers.
+func rouletteNumbers(wheel rouletteWheel, bet models.RouletteBet) ([]int, error) {
+	switch bet.Kind {
+	case "number":
+		n, ok := intValue(bet.Value)
+		if bet.Value == "00" {
+			n, ok = models.DoubleZero, true
+		}
+		if !ok || !wheel.hasNumber(n) {
+			return nil, errInvalidRouletteBet
+		}
+		return []int{n}, nil
+	case "split", "street", "corner", "sixline":
+		numbers := append([]int(nil), bet.Numbers...)
+		sort.Ints(numbers)
+		if !legalInsideBet(wheel, bet.Kind, numbers) {
+			return nil, errIllegalNumbers
+		}
+		return numbers, nil
+	case "fivenumber":
+		// The top line of the American layout: 0, 00, 1, 2 and 3.
+		if !wheel.doubleZero() {
+			return nil, errUnknownRouletteBet
+		}
+		return []int{0, 1, 2, 3, models.DoubleZero}, nil
+	case "color":
+		color, _ := bet.Value.(string)
+		if color != "red" && color != "black" {
+			return nil, errInvalidRouletteBet
+		}
+		return outsideNumbers(func(n int) bool { return pocketColor(n) == color }), nil
+	case "parity":
+		parity, _ := bet.Value.(string)
+		if parity != "even" && parity != "odd" {
+			return nil, errInvalidRouletteBet
+		}
+		return outsideNumbers(func(n int) bool { return (n%2 == 0) == (parity == "even") }), nil
+	case "highlow":
+		half, _ := bet.Value.(string)
+		if half != "low" && half != "high" {
+			return nil, errInvalidRouletteBet
+		}
+		return outsideNumbers(func(n int) bool { return (n <= 18) == (half == "low") }), nil
+	case "dozen":
+		dozen, ok := intValue(bet.Value)
+		if !ok || dozen < 1 || dozen > 3 {
+			return nil, errInvalidRouletteBet
+		}
+		return outsideNumbers(func(n int) bool { return (n-1)/12+1 == dozen }), nil
+	case "column":
+		col, ok := intValue(bet.Value)
+		if !ok || col < 1 || col > 3 {
+			return nil, errInvalidRouletteBet
+		}
+		return outsideNumbers(func(n int) bool { return (n-1)%3+1 == col }), nil
+	}
+	return nil, errUnknownRouletteBet
+}
+
+// The inside bets that take in a zero on each kind of wheel, sorted, with 00
+// written as DoubleZero.
+var (
+	singleZeroInsideBets = map[string][][]int{
+		"split":  {{0, 1}, {0, 2}, {0, 3}},
+		"street": {{0, 1, 2}, {0, 2, 3}},
+		// The first four.
+		"corner": {{0, 1, 2, 3}},
+	}
+	doubleZeroInsideBets = map[string][][]int{
+		"split":  {{0, 1}, {0, 2}, {0, models.DoubleZero}, {2, models.DoubleZero}, {3, models.DoubleZero}},
+		"street": {{0, 1, 2}, {0, 2, models.DoubleZero}, {2, 3, models.DoubleZero}},
+	}
+)
+
+// legalInsideBet reports whether sorted numbers can be covered by one chip
+// of the given kind. The layout has 12 rows of three, 1-2-3 at the top, with
+// the zeros above the first row.
+func legalInsideBet(wheel rouletteWheel, kind string, n []int) bool {
+	if len(n) == 0 {
+		return false
+	}
+	for _, v := range n {
+		if !wheel.hasNumber(v) {
+			return false
+		}
+	}
+	if wheel.isZero(n[0]) || wheel.isZero(n[len(n)-1]) {
+		zeroBets := singleZeroInsideBets
+		if wheel.doubleZero() {
+			zeroBets = doubleZeroInsideBets
+		}
+		for _, legal := range zeroBets[kind] {
+			if sameNumbers(legal, n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch kind {
+	case "split":
+		if len(n) != 2 {
+			return false
+		}
+		return n[1]-n[0] == 3 || (n[1]-n[0] == 1 && n[0]%3 != 0)
+	case "street":
+		if len(n) != 3 {
+			return false
+		}
+		return n[0]%3 == 1 && n[1] == n[0]+1 && n[2] == n[0]+2
+	case "corner":
+		if len(n) != 4 {
+			return false
+		}
+		return n[0]%3 != 0 && n[1] == n[0]+1 && n[2] == n[0]+3 && n[3] == n[0]+4
+	case "sixline":
+		if len(n) != 6 || n[0]%3 != 1 {
+			return false
+		}
+		for i := 1; i < 6; i++ {
+			if n[i] != n[0]+i {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func sameNumbers(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// outsideNumbers returns the numbers from 1 to 36 that match; outside bets
+// never cover zero.
+func outsideNumbers(match func(int) bool) []int {
+	var numbers []int
+	for n := 1; n <= 36; n++ {
+		if match(n) {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+func pocketColor(n int) string {
+	for _, p := range models.POCKETS {
+		if p.N == n {
+			return p.Color
+		}
+	}
+	return ""
+}
+
+// intValue reads a whole number from a decoded JSON value.
+func intValue(v interface{}) (int, bool) {
+	f, ok := v.(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// settle works out the bet against the winning number. The payout includes
+// the stake of every winning chip. prisonNumber is the next spin, which only
+// matters to even-money bets held En Prison.
+func (w rouletteWager) settle(wheel rouletteWheel, winningNumber, prisonNumber int) models.RouletteBetResult {
+	res := models.RouletteBetResult{Kind: w.bet.Kind, Value: w.bet.Value, Stake: w.stake}
+	for _, c := range w.chips {
+		res.Numbers = append(res.Numbers, c.numbers...)
+		switch {
+		case c.covers(winningNumber):
+			res.Won = true
+			res.Payout += c.stake + c.stake.Times(c.pays())
+		case !c.evenMoney() || !wheel.isZero(winningNumber):
+		case wheel.EvenMoney == laPartage:
+			res.Payout += c.stake.MulFrac(1, 2)
+		case wheel.EvenMoney == enPrison:
+			res.Imprisoned = true
+			if c.covers(prisonNumber) {
+				res.Payout += c.stake
+			}
+		}
+	}
+	res.Numbers = uniqueNumbers(res.Numbers)
+	return res
+}
+
+func uniqueNumbers(numbers []int) []int {
+	sort.Ints(numbers)
+	out := numbers[:0]
+	for i, n := range numbers {
+		if i == 0 || n != numbers[i-1] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLegalInsideBet(t *testing.T) {
+	const dz = models.DoubleZero
+	tests := []struct {
+		kind               string
+		numbers            []int
+		european, american bool
+	}{
+		{"split", []int{1, 2}, true, true},
+		{"split", []int{1, 4}, true, true},
+		{"split", []int{33, 36}, true, true},
+		{"split", []int{35, 36}, true, true},
+		{"split", []int{3, 4}, false, false},
+		{"split", []int{1, 3}, false, false},
+		{"split", []int{1, 5}, false, false},
+		{"split", []int{1}, false, false},
+		{"split", []int{0, 1}, true, true},
+		{"split", []int{0, 2}, true, true},
+		{"split", []int{0, 3}, true, false},
+		{"split", []int{0, dz}, false, true},
+		{"split", []int{2, dz}, false, true},
+		{"split", []int{3, dz}, false, true},
+		{"split", []int{1, dz}, false, false},
+		{"split", []int{36, 37}, false, false},
+		{"street", []int{1, 2, 3}, true, true},
+		{"street", []int{34, 35, 36}, true, true},
+		{"street", []int{2, 3, 4}, false, false},
+		{"street", []int{1, 2, 4}, false, false},
+		{"street", []int{0, 1, 2}, true, true},
+		{"street", []int{0, 2, 3}, true, false},
+		{"street", []int{0, 2, dz}, false, true},
+		{"street", []int{2, 3, dz}, false, true},
+		{"street", []int{1, 2, 3, 4}, false, false},
+		{"corner", []int{1, 2, 4, 5}, true, true},
+		{"corner", []int{2, 3, 5, 6}, true, true},
+		{"corner", []int{32, 33, 35, 36}, true, true},
+		{"corner", []int{3, 4, 6, 7}, false, false},
+		{"corner", []int{1, 2, 3, 4}, false, false},
+		{"corner", []int{0, 1, 2, 3}, true, false},
+		{"corner", []int{0, 2, 3, dz}, false, false},
+		{"sixline", []int{1, 2, 3, 4, 5, 6}, true, true},
+		{"sixline", []int{31, 32, 33, 34, 35, 36}, true, true},
+		{"sixline", []int{2, 3, 4, 5, 6, 7}, false, false},
+		{"sixline", []int{1, 2, 3, 4, 5, 7}, false, false},
+		{"sixline", []int{0, 1, 2, 3, 4, 5}, false, false},
+		{"sixline", []int{34, 35, 36, 37, 38, 39}, false, false},
+		{"basket", []int{0, 1, 2, 3}, false, false},
+		{"split", nil, false, false},
+	}
+	for _, tt := range tests {
+		for _, wheel := range []struct {
+			variant string
+			want    bool
+		}{{"european", tt.european}, {"american", tt.american}} {
+			if got := legalInsideBet(rouletteWheels[wheel.variant], tt.kind, tt.numbers); got != wheel.want {
+				t.Errorf("%s %s %v = %v, want %v", wheel.variant, tt.kind, tt.numbers, got, wheel.want)
+			}
+		}
+	}
+}
+
+func TestRouletteChips(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant string
+		bet     models.RouletteBet
+		want    []rouletteChip
+		err     error
+	}{
+		{"split numbers are sorted", "european",
+			models.RouletteBet{Kind: "split", Numbers: []int{5, 2}, Stake: 100},
+			[]rouletteChip{{[]int{2, 5}, 100}}, nil},
+		{"illegal corner", "european",
+			models.RouletteBet{Kind: "corner", Numbers: []int{3, 4, 6, 7}, Stake: 100},
+			nil, errIllegalNumbers},
+		{"double zero on a single-zero wheel", "european",
+			models.RouletteBet{Kind: "number", Value: "00", Stake: 100},
+			nil, errInvalidRouletteBet},
+		{"double zero", "american",
+			models.RouletteBet{Kind: "number", Value: "00", Stake: 100},
+			[]rouletteChip{{[]int{models.DoubleZero}, 100}}, nil},
+		{"five number", "american",
+			models.RouletteBet{Kind: "fivenumber", Stake: 100},
+			[]rouletteChip{{[]int{0, 1, 2, 3, models.DoubleZero}, 100}}, nil},
+		{"five number on a single-zero wheel", "french",
+			models.RouletteBet{Kind: "fivenumber", Stake: 100},
+			nil, errUnknownRouletteBet},
+		{"voisins in nine units", "european",
+			models.RouletteBet{Kind: "voisins", Stake: money.Coins(9)},
+			[]rouletteChip{
+				{[]int{0, 2, 3}, money.Coins(2)},
+				{[]int{4, 7}, money.Coins(1)},
+				{[]int{12, 15}, money.Coins(1)},
+				{[]int{18, 21}, money.Coins(1)},
+				{[]int{19, 22}, money.Coins(1)},
+				{[]int{32, 35}, money.Coins(1)},
+				{[]int{25, 26, 28, 29}, money.Coins(2)},
+			}, nil},
+		{"tiers in six units", "french",
+			models.RouletteBet{Kind: "tiers", Stake: 60},
+			[]rouletteChip{
+				{[]int{5, 8}, 10},
+				{[]int{10, 11}, 10},
+				{[]int{13, 16}, 10},
+				{[]int{23, 24}, 10},
+				{[]int{27, 30}, 10},
+				{[]int{33, 36}, 10},
+			}, nil},
+		{"orphelins in five units", "french-en-prison",
+			models.RouletteBet{Kind: "orphelins", Stake: 500},
+			[]rouletteChip{
+				{[]int{1}, 100},
+				{[]int{6, 9}, 100},
+				{[]int{14, 17}, 100},
+				{[]int{17, 20}, 100},
+				{[]int{31, 34}, 100},
+			}, nil},
+		{"call bet on a double-zero wheel", "american",
+			models.RouletteBet{Kind: "tiers", Stake: 600},
+			nil, errCallBetWheel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chips, err := rouletteChips(rouletteWheels[tt.variant], tt.bet)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(chips, tt.want) {
+				t.Errorf("chips = %v, want %v", chips, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallBetStakeMustSplitEvenly(t *testing.T) {
+	for kind, stake := range map[string]money.Amount{"voisins": 1000, "tiers": 700, "orphelins": 1} {
+		bet := models.RouletteBet{Kind: kind, Stake: stake}
+		if _, err := rouletteChips(rouletteWheels["european"], bet); err == nil {
+			t.Errorf("%s with a stake of %d was accepted", kind, stake)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRouletteHistory = 20
+	maxRouletteHistory     = 500
+	// maxRouletteWindow is the largest window statistics can be asked for.
+	maxRouletteWindow  = 1000
+	maxRouletteWindows = 5
+	// rouletteHotCold is how many hot and cold numbers a window lists.
+	rouletteHotCold = 5
+)
+
+var defaultRouletteWindows = []int{100, 500}
+
+func recordRouletteSpin(tx *sql.Tx, roundID string, userID int, variant string, winning models.RoulettePocket, prisonNumber *int) error {
+	_, err := tx.Exec(`
+		INSERT INTO roulette_spins (round_id, user_id, variant, number, color, prison_number, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		roundID, userID, variant, winning.N, winning.Color, prisonNumber, time.Now())
+	return err
+}
+
+// GetRouletteHistory godoc
+// @Summary Get roulette spin history
+// @Description Returns the last spins of a wheel with hot and cold numbers, colour ratios and dozen and column frequencies over each window
+// @Tags roulette
+// @Produce json
+// @Param variant query string false "Wheel variant (default european)"
+// @Param limit query int false "Number of spins to list (default 20, max 500)"
+// @Param windows query string false "Comma-separated window sizes (default 100,500, max 1000)"
+// @Success 200 {object} models.RouletteHistory
+// @Failure 400 {string} string "Invalid query"
+// @Router /api/v1/roulette/history [get]
+func GetRouletteHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	wheel, ok := lookupRouletteWheel(q.Get("variant"))
+	if !ok {
+		http.Error(w, "Invalid variant", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultRouletteHistory
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxRouletteHistory {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	windows := defaultRouletteWindows
+	if v := q.Get("windows"); v != "" {
+		windows = nil
+		for _, part := range strings.Split(v, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n <= 0 || n > maxRouletteWindow {
+				http.Error(w, "Invalid windows", http.StatusBadRequest)
+				return
+			}
+			windows = append(windows, n)
+		}
+		if len(windows) > maxRouletteWindows {
+			http.Error(w, "Too many windows", http.StatusBadRequest)
+			return
+		}
+	}
+
+	fetch := limit
+	for _, size := range windows {
+		fetch = max(fetch, size)
+	}
+	rows, err := database.DB.Query(`
+		SELECT number, color, created_at
+		FROM roulette_spins
+		WHERE variant = ?
+		ORDER BY id DESC
+		LIMIT ?`, wheel.Variant, fetch)
+	if err != nil {
+		log.Println("GetRouletteHistory error:", err)
+		http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	// Newest first.
+	spins := []models.RouletteSpin{}
+	for rows.Next() {
+		s := models.RouletteSpin{Variant: wheel.Variant}
+		if err := rows.Scan(&s.Number, &s.Color, &s.SpunAt); err != nil {
+			log.Println("GetRouletteHistory error:", err)
+			http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+			return
+		}
+		s.Pocket = pocketLabel(s.Number)
+		spins = append(spins, s)
+	}
+	if err := rows.Err(); err != nil {
+		log.Println("GetRouletteHistory error:", err)
+		http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+		return
+	}
+
+	history := models.RouletteHistory{Variant: wheel.Variant, Spins: spins[:min(limit, len(spins))]}
+	for _, size := range windows {
+		history.Windows = append(history.Windows, rouletteWindowStats(wheel, spins[:min(size, len(spins))], size))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// rouletteWindowStats works out the statistics of a window of spins.
+func rouletteWindowStats(wheel rouletteWheel, spins []models.RouletteSpin, size int) models.RouletteWindowStats {
+	stats := models.RouletteWindowStats{Size: size, Spins: len(spins)}
+
+	counts := map[int]int{}
+	var red, black, green int
+	dozens, columns := make([]int, 3), make([]int, 3)
+	for _, s := range spins {
+		counts[s.Number]++
+		switch s.Color {
+		case "red":
+			red++
+		case "black":
+			black++
+		default:
+			green++
+		}
+		if !wheel.isZero(s.Number) {
+			dozens[(s.Number-1)/12]++
+			columns[(s.Number-1)%3]++
+		}
+	}
+
+	numbers := make([]models.RouletteNumberCount, 0, len(wheel.Pockets))
+	for _, p := range wheel.Pockets {
+		numbers = append(numbers, models.RouletteNumberCount{Number: p.N, Pocket: pocketLabel(p.N), Count: counts[p.N]})
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		if numbers[i].Count != numbers[j].Count {
+			return numbers[i].Count > numbers[j].Count
+		}
+		return numbers[i].Number < numbers[j].Number
+	})
+	// A number that has not come up is not hot.
+	stats.Hot = []models.RouletteNumberCount{}
+	for _, n := range numbers[:rouletteHotCold] {
+		if n.Count > 0 {
+			stats.Hot = append(stats.Hot, n)
+		}
+	}
+	sort.SliceStable(numbers, func(i, j int) bool { return numbers[i].Count < numbers[j].Count })
+	stats.Cold = numbers[:rouletteHotCold]
+
+	stats.Colors = models.RouletteColorRatios{
+		Red:   ratio(red, len(spins)),
+		Black: ratio(black, len(spins)),
+		Green: ratio(green, len(spins)),
+	}
+	for i := range 3 {
+		stats.Dozens = append(stats.Dozens, models.RouletteTally{Value: i + 1, Count: dozens[i], Frequency: ratio(dozens[i], len(spins))})
+		stats.Columns = append(stats.Columns, models.RouletteTally{Value: i + 1, Count: columns[i], Frequency: ratio(columns[i], len(spins))})
+	}
+	return stats
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
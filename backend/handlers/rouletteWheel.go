@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// What happens to even-money bets when the ball lands on zero.
+const (
+	evenMoneyLost = "lost"
+	// La Partage returns half the stake.
+	laPartage = "la-partage"
+	// En Prison holds the stake for one more spin, returning it if the bet
+	// wins that spin and losing it otherwise.
+	enPrison = "en-prison"
+)
+
+const defaultRouletteVariant = "european"
+
+var errUnknownVariant = errors.New("unknown roulette variant")
+
+// rouletteWheel is a wheel variant and the rules it is played under.
+type rouletteWheel struct {
+	Variant   string
+	Name      string
+	Pockets   []models.RoulettePocket
+	EvenMoney string
+}
+
+var rouletteWheels = map[string]rouletteWheel{
+	"european": {Variant: "european", Name: "European", Pockets: models.POCKETS, EvenMoney: evenMoneyLost},
+	"american": {Variant: "american", Name: "American", Pockets: models.AMERICAN_POCKETS, EvenMoney: evenMoneyLost},
+	"french":   {Variant: "french", Name: "French (La Partage)", Pockets: models.POCKETS, EvenMoney: laPartage},
+	"french-en-prison": {Variant: "french-en-prison", Name: "French (En Prison)", Pockets: models.POCKETS,
+		EvenMoney: enPrison},
+}
+
+// rouletteVariantOrder lists the variants in display order.
+var rouletteVariantOrder = []string{"european", "french", "french-en-prison", "american"}
+
+// lookupRouletteWheel resolves a variant name, treating an empty name as the
+// European wheel.
+func lookupRouletteWheel(variant string) (rouletteWheel, bool) {
+	if variant == "" {
+		variant = defaultRouletteVariant
+	}
+	wheel, ok := rouletteWheels[variant]
+	return wheel, ok
+}
+
+func (wh rouletteWheel) doubleZero() bool {
+	return len(wh.Pockets) == 38
+}
+
+func (wh rouletteWheel) isZero(n int) bool {
+	return n == 0 || n == models.DoubleZero
+}
+
+// hasNumber reports whether n is a pocket on the wheel.
+func (wh rouletteWheel) hasNumber(n int) bool {
+	return (n >= 0 && n <= 36) || (n == models.DoubleZero && wh.doubleZero())
+}
+
+// pocketLabel is a number as printed on the wheel.
+func pocketLabel(n int) string {
+	if n == models.DoubleZero {
+		return "00"
+	}
+	return strconv.Itoa(n)
+}
+
+// RouletteHouseEdge works out the exact house edge of a bet on a wheel
+// variant, as a fraction of the stake, by settling it against every pocket
+// (and, for bets held En Prison, every prison spin).
+func RouletteHouseEdge(variant string, bet models.RouletteBet) (float64, error) {
+	wheel, ok := lookupRouletteWheel(variant)
+	if !ok {
+		return 0, errUnknownVariant
+	}
+	if bet.Stake == 0 {
+		bet.Stake = money.Coins(1)
+	}
+	chips, err := rouletteChips(wheel, bet)
+	if err != nil {
+		return 0, err
+	}
+	wager := rouletteWager{bet: bet, stake: bet.Stake, chips: chips}
+
+	var returned money.Amount
+	for _, p := range wheel.Pockets {
+		for _, q := range wheel.Pockets {
+			returned += wager.settle(wheel, p.N, q.N).Payout
+		}
+	}
+	n := float64(len(wheel.Pockets))
+	return 1 - float64(returned)/(n*n*float64(bet.Stake)), nil
+}
+
+// ListRouletteVariants godoc
+// @Summary List roulette wheels
+// @Description Returns the roulette wheel variants with their even-money rule and house edge
+// @Tags roulette
+// @Produce json
+// @Success 200 {array} models.RouletteVariant
+// @Router /api/v1/roulette/variants [get]
+func ListRouletteVariants(w http.ResponseWriter, r *http.Request) {
+	variants := make([]models.RouletteVariant, 0, len(rouletteVariantOrder))
+	for _, v := range rouletteVariantOrder {
+		wheel := rouletteWheels[v]
+		straight, _ := RouletteHouseEdge(v, models.RouletteBet{Kind: "number", Value: float64(17)})
+		evenMoney, _ := RouletteHouseEdge(v, models.RouletteBet{Kind: "color", Value: "red"})
+		variants = append(variants, models.RouletteVariant{
+			Variant:            wheel.Variant,
+			Name:               wheel.Name,
+			Pockets:            len(wheel.Pockets),
+			EvenMoneyRule:      wheel.EvenMoney,
+			HouseEdge:          straight,
+			EvenMoneyHouseEdge: evenMoney,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(variants)
+}
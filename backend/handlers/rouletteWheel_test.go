@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRouletteHouseEdge(t *testing.T) {
+	straight := models.RouletteBet{Kind: "number", Value: float64(17)}
+	red := models.RouletteBet{Kind: "color", Value: "red"}
+	tests := []struct {
+		variant string
+		name    string
+		bet     models.RouletteBet
+		want    float64
+	}{
+		{"european", "straight up", straight, 1.0 / 37},
+		{"european", "even money", red, 1.0 / 37},
+		{"european", "voisins", models.RouletteBet{Kind: "voisins", Stake: 900}, 1.0 / 37},
+		{"american", "straight up", straight, 2.0 / 38},
+		{"american", "even money", red, 2.0 / 38},
+		{"american", "five number", models.RouletteBet{Kind: "fivenumber"}, 3.0 / 38},
+		{"french", "straight up", straight, 1.0 / 37},
+		{"french", "even money", red, 1.0 / 74},
+		{"french", "orphelins", models.RouletteBet{Kind: "orphelins", Stake: 500}, 1.0 / 37},
+		{"french-en-prison", "straight up", straight, 1.0 / 37},
+		// Held on zero, the bet comes back if the next spin wins it.
+		{"french-en-prison", "even money", red, 19.0 / 1369},
+	}
+	for _, tt := range tests {
+		t.Run(tt.variant+" "+tt.name, func(t *testing.T) {
+			got, err := RouletteHouseEdge(tt.variant, tt.bet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("house edge = %.4f%%, want %.4f%%", got*100, tt.want*100)
+			}
+		})
+	}
+
+	// The top line is the worst bet on the American layout.
+	edge, _ := RouletteHouseEdge("american", models.RouletteBet{Kind: "fivenumber"})
+	if math.Round(edge*10000) != 789 {
+		t.Errorf("five number house edge = %.2f%%, want 7.89%%", edge*100)
+	}
+
+	if _, err := RouletteHouseEdge("mini", straight); err != errUnknownVariant {
+		t.Errorf("unknown variant err = %v, want %v", err, errUnknownVariant)
+	}
+}
+
+func TestCallBetNumbers(t *testing.T) {
+	want := map[string][]int{
+		"voisins":   {0, 2, 3, 4, 7, 12, 15, 18, 19, 21, 22, 25, 26, 28, 29, 32, 35},
+		"tiers":     {5, 8, 10, 11, 13, 16, 23, 24, 27, 30, 33, 36},
+		"orphelins": {1, 6, 9, 14, 17, 20, 31, 34},
+	}
+	if len(callBets) != len(want) {
+		t.Errorf("%d call bets, want %d", len(callBets), len(want))
+	}
+
+	seen := map[int]string{}
+	for kind, numbers := range want {
+		var got []int
+		for _, c := range callBets[kind] {
+			got = append(got, c.numbers...)
+		}
+		got = uniqueNumbers(got)
+		if !reflect.DeepEqual(got, numbers) {
+			t.Errorf("%s covers %v, want %v", kind, got, numbers)
+		}
+		for _, n := range got {
+			if other, ok := seen[n]; ok {
+				t.Errorf("%d is covered by both %s and %s", n, other, kind)
+			}
+			seen[n] = kind
+		}
+	}
+
+	// Between them the three cover the whole single-zero wheel.
+	var all []int
+	for n := range seen {
+		all = append(all, n)
+	}
+	sort.Ints(all)
+	for i, n := range all {
+		if n != i {
+			t.Fatalf("call bets cover %v, want 0 to 36", all)
+		}
+	}
+	if len(all) != 37 {
+		t.Errorf("call bets cover %d numbers, want 37", len(all))
+	}
+}
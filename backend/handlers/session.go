@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/session"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// GetSession godoc
+// @Summary Get the current play session
+// @Description Returns how long the user has been playing, their net result per currency and whether a reality check is due
+// @Tags session
+// @Produce json
+// @Success 200 {object} models.RealityCheck
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/session [get]
+func GetSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := session.Report(userID)
+	if err != nil {
+		log.Println("GetSession error:", err)
+		http.Error(w, "Failed to fetch session", http.StatusInternalServerError)
+		return
+	}
+	writeRealityCheck(w, report)
+}
+
+// AcknowledgeRealityCheck godoc
+// @Summary Acknowledge a reality check
+// @Description Confirms the user has seen their session summary. Bets are refused while a reality check is due.
+// @Tags session
+// @Produce json
+// @Success 200 {object} models.RealityCheck
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/session/ack [post]
+func AcknowledgeRealityCheck(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := session.Acknowledge(userID)
+	if err != nil {
+		log.Println("AcknowledgeRealityCheck error:", err)
+		http.Error(w, "Failed to acknowledge reality check", http.StatusInternalServerError)
+		return
+	}
+	writeRealityCheck(w, report)
+}
+
+func writeRealityCheck(w http.ResponseWriter, report models.RealityCheck) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
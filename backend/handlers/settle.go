@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"casino-hub/backend/limits"
+	"casino-hub/backend/money"
+	"casino-hub/backend/session"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// betResult is a settled bet plus what the player's session says about it.
+type betResult struct {
+	wallet.Settlement
+	// RealityCheckDue is set when this bet took the session past a reality
+	// check; further bets are refused until the player acknowledges it.
+	RealityCheckDue bool
+}
+
+// settleBet runs a round through wallet.Settle and writes the error response
+// itself when settlement fails. Handlers return as soon as ok is false.
+//
+// Every bet is checked against the player's responsible gaming limits and
+// counts as activity in their play session.
+func settleBet(w http.ResponseWriter, bet wallet.Bet, play func(tx *sql.Tx) (money.Amount, error)) (betResult, bool) {
+	var res betResult
+	bet.Check = res.check
+	s, err := wallet.Settle(bet, play)
+	res.Settlement = s
+	if err != nil {
+		writeSettlementError(w, err)
+		return res, false
+	}
+	return res, true
+}
+
+// settleBetTx is settleBet inside tx, for further stakes on a round in play.
+func settleBetTx(w http.ResponseWriter, tx *sql.Tx, bet wallet.Bet, play func(tx *sql.Tx) (money.Amount, error)) (betResult, bool) {
+	var res betResult
+	bet.Check = res.check
+	s, err := wallet.SettleTx(tx, bet, play)
+	res.Settlement = s
+	if err != nil {
+		writeSettlementError(w, err)
+		return res, false
+	}
+	return res, true
+}
+
+func (res *betResult) check(tx *sql.Tx, bet wallet.Bet) error {
+	if err := limits.CheckBet(tx, bet); err != nil {
+		return err
+	}
+	s, err := session.TouchTx(tx, bet.UserID, time.Now())
+	res.RealityCheckDue = s.RealityCheckPending
+	return err
+}
+
+func writeSettlementError(w http.ResponseWriter, err error) {
+	var limit *wallet.StakeLimitError
+	var rg *limits.LimitError
+	switch {
+	case errors.As(err, &rg):
+		writeError(w, http.StatusForbidden, rg.Code(), limitMessage(rg), rg)
+	case errors.Is(err, session.ErrRealityCheckDue):
+		writeError(w, http.StatusForbidden, "reality_check_due", "Reality check: review your session and acknowledge it to keep playing.", nil)
+	case errors.Is(err, wallet.ErrInsufficientFunds):
+		http.Error(w, "Insufficient balance", http.StatusBadRequest)
+	case errors.Is(err, wallet.ErrInvalidStake):
+		http.Error(w, "Invalid bet amount", http.StatusBadRequest)
+	case errors.As(err, &limit):
+		msg := fmt.Sprintf("Bet must be at least %s %s", limit.Min, limit.Currency)
+		if limit.Max > 0 {
+			msg = fmt.Sprintf("Bet must be between %s and %s %s", limit.Min, limit.Max, limit.Currency)
+		}
+		http.Error(w, msg, http.StatusBadRequest)
+	case errors.Is(err, wallet.ErrUnknownCurrency):
+		http.Error(w, "Unknown currency", http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "User not found", http.StatusNotFound)
+	default:
+		log.Println("Settlement error:", err)
+		http.Error(w, "Could not update balance", http.StatusInternalServerError)
+	}
+}
+
+func limitMessage(e *limits.LimitError) string {
+	if e.Type == limits.SessionLength {
+		return fmt.Sprintf("You have reached your session limit of %d minutes. Take a break before playing again.", e.Minutes)
+	}
+	return fmt.Sprintf("This bet would exceed your %s %s limit of %s %s.", e.Period, e.Type, e.Limit, e.Currency)
+}
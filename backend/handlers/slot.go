@@ -3,6 +3,9 @@ package handlers
 import (
 	"casino-hub/backend/database"
 	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -11,6 +14,10 @@ import (
 )
 
 
+// RecordGamePlay counts a finished round towards the player's game stats.
+// By the time it runs the round is settled, so callers only log its errors
+// and still answer with the result; a 500 here would let an idempotent retry
+// play the round again.
 func RecordGamePlay(userID int, gameTitle string) error {
     var gameID int
     err := database.DB.QueryRow("SELECT id FROM games WHERE title = ?", gameTitle).Scan(&gameID)
@@ -48,44 +55,37 @@ func SpinSlot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	var balance int64
-	err := database.DB.QueryRow("SELECT balance FROM users WHERE id = ?", userID).Scan(&balance)
-	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
-		return
-	}
-	
-	if balance < req.BetAmount {
-		http.Error(w, "Insufficient balance", http.StatusBadRequest)
-		return
-	}
-	
-	results := GenerateSymbols()
-	winAmount, winType, multiplier := CalculateWin(results, req.BetAmount)
-	
-	newBalance := balance - req.BetAmount + winAmount
-	_, err = database.DB.Exec("UPDATE users SET balance = ? WHERE id = ?", newBalance, userID)
-	if err != nil {
-		http.Error(w, "Could not update balance", http.StatusInternalServerError)
+	var results []int
+	var winAmount money.Amount
+	var winType string
+	var multiplier float64
+	settlement, ok := settleBet(w, wallet.Bet{UserID: userID, Game: "Slot", Currency: req.Currency, Stake: req.BetAmount}, func(tx *sql.Tx) (money.Amount, error) {
+		results = GenerateSymbols()
+		winAmount, winType, multiplier = CalculateWin(results, req.BetAmount)
+		return winAmount, nil
+	})
+	if !ok {
 		return
 	}
+	mystery, newBalance := dropMysteryJackpot(userID, "Slot", settlement)
 
 	if err := RecordGamePlay(userID, "Slot"); err != nil {
 		fmt.Println("RecordGamePlay error:", err)
-		http.Error(w, "Failed to record game play", http.StatusInternalServerError)
-		return
 	}
 	
 	
 	res := models.SpinResult{
-		Success:    true,
-		Symbols:    results,
-		WinAmount:  winAmount,
-		NewBalance: newBalance,
-		WinType:    winType,
-		JackpotWin: false,
-		Multiplier: multiplier,
-		Message:    "Spin completed",
+		Success:         true,
+		Symbols:         results,
+		WinAmount:       winAmount,
+		NewBalance:      newBalance,
+		Currency:        settlement.Currency,
+		RealityCheckDue: settlement.RealityCheckDue,
+		WinType:         winType,
+		JackpotWin:      len(mystery) > 0,
+		MysteryJackpots: mystery,
+		Multiplier:      multiplier,
+		Message:         "Spin completed",
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
@@ -141,7 +141,7 @@ func GenerateSymbols() []int {
 }
 
 
-func CalculateWin(results []int, bet int64) (int64, string, float64) {
+func CalculateWin(results []int, bet money.Amount) (money.Amount, string, float64) {
 	symbolCounts := make(map[int]int)
 
 	for _, symbolIndex := range results {
@@ -149,14 +149,14 @@ func CalculateWin(results []int, bet int64) (int64, string, float64) {
 		symbolCounts[symbolID]++
 	}
 
-	var totalWin int64 = 0
+	var totalWin money.Amount = 0
 	winType := "none"
 	multiplier := 1.0
 
 	for symbolID, count := range symbolCounts {
 		if count >= 3 {
 			symbol := symbols[symbolID-1]
-			baseWin := bet * int64(symbol.Multiplier)
+			baseWin := bet.Times(int64(symbol.Multiplier))
 
 			switch count {
 			case 3:
@@ -169,14 +169,14 @@ func CalculateWin(results []int, bet int64) (int64, string, float64) {
 				winType = "mega"
 			}
 
-			totalWin += int64(float64(baseWin) * multiplier)
+			totalWin += baseWin.Times(int64(multiplier))
 		}
 	}
 
 	if totalWin > 0 {
-		if totalWin >= bet*50 {
+		if totalWin >= bet.Times(50) {
 			winType = "mega"
-		} else if totalWin >= bet*10 {
+		} else if totalWin >= bet.Times(10) {
 			winType = "big"
 		} else {
 			winType = "normal"
@@ -5,6 +5,7 @@ import (
     "net/http"
     "casino-hub/backend/database"
     "casino-hub/backend/models"
+    "casino-hub/backend/wallet"
 )
 
 func RegisterUser (w http.ResponseWriter, r *http.Request) {
@@ -14,10 +15,28 @@ func RegisterUser (w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := database.DB.Exec("INSERT INTO users (username, password, balance) VALUES (?, ?, ?)", user.Username, user.Password, user.Balance)
+	tx, err := database.DB.Begin()
 	if err != nil {
 		http.Error(w, "DB error", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO users (username, password, balance) VALUES (?, ?, 0)", user.Username, user.Password)
+	if err != nil {
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	if user.Balance > 0 {
+		id, _ := res.LastInsertId()
+		if _, err := wallet.PostTx(tx, wallet.Entry{UserID: int(id), Type: wallet.Credit, Amount: user.Balance, Reason: wallet.ReasonAdjustment}); err != nil {
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
 	w.WriteHeader(http.StatusCreated)
 }
\ No newline at end of file
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GetWallet godoc
+// @Summary Get wallet summary
+// @Description Returns cash and bonus balances of every currency plus wagering progress of active bonus grants
+// @Tags wallet
+// @Produce json
+// @Success 200 {object} models.Wallet
+// @Failure 401 {string} string "Unauthorized"
+// @Router /api/v1/wallet [get]
+func GetWallet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	summary, err := wallet.Summary(userID)
+	if err != nil {
+		log.Println("GetWallet error:", err)
+		http.Error(w, "Failed to fetch wallet", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// parseTransactionFilter reads currency, game, type, subWallet, from and to
+// from the query string.
+// Dates may be RFC3339 timestamps or plain YYYY-MM-DD days; a plain "to" day
+// is included in full.
+func parseTransactionFilter(userID int, q url.Values) (wallet.Filter, error) {
+	f := wallet.Filter{UserID: userID, Game: q.Get("game")}
+
+	if c := q.Get("currency"); c != "" {
+		if _, err := wallet.LookupCurrency(c); err != nil {
+			return f, fmt.Errorf("unknown currency")
+		}
+		f.Currency = c
+	}
+
+	switch sw := q.Get("subWallet"); sw {
+	case "", wallet.SubWalletCash, wallet.SubWalletBonus:
+		f.SubWallet = sw
+	default:
+		return f, fmt.Errorf("subWallet must be cash or bonus")
+	}
+
+	switch t := wallet.Type(q.Get("type")); t {
+	case "", wallet.Debit, wallet.Credit:
+		f.Type = t
+	default:
+		return f, fmt.Errorf("type must be debit or credit")
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, _, err = parseDateParam(v); err != nil {
+			return f, fmt.Errorf("invalid from date")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		to, dayOnly, err := parseDateParam(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid to date")
+		}
+		if dayOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		f.To = to
+	}
+	return f, nil
+}
+
+func parseDateParam(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	return t, true, err
+}
+
+// GetTransactions godoc
+// @Summary List wallet transactions
+// @Description Returns the logged-in user's ledger, newest first, with cursor pagination
+// @Tags wallet
+// @Produce json
+// @Param currency query string false "Currency code"
+// @Param game query string false "Game title"
+// @Param type query string false "debit or credit"
+// @Param subWallet query string false "cash or bonus"
+// @Param from query string false "Start date (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "End date (RFC3339 or YYYY-MM-DD)"
+// @Param cursor query int false "nextCursor from the previous page"
+// @Param limit query int false "Page size (max 200)"
+// @Success 200 {object} wallet.Page
+// @Failure 400 {string} string "Invalid request"
+// @Router /api/v1/wallet/transactions [get]
+func GetTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	f, err := parseTransactionFilter(userID, q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("cursor"); v != "" {
+		if f.Cursor, err = strconv.ParseInt(v, 10, 64); err != nil || f.Cursor <= 0 {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := wallet.List(f)
+	if err != nil {
+		log.Println("GetTransactions error:", err)
+		http.Error(w, "Failed to fetch transactions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// ExportTransactions godoc
+// @Summary Export wallet statement
+// @Description Returns the logged-in user's transactions for a period as CSV, oldest first, opened by the starting balance
+// @Tags wallet
+// @Produce text/csv
+// @Param currency query string false "Currency code (default GC)"
+// @Param game query string false "Game title"
+// @Param type query string false "debit or credit"
+// @Param subWallet query string false "cash (default) or bonus"
+// @Param from query string false "Start date (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "End date (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {string} string "CSV statement"
+// @Failure 400 {string} string "Invalid request"
+// @Router /api/v1/wallet/transactions/export [get]
+func ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	f, err := parseTransactionFilter(userID, r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opening, txs, err := wallet.Statement(f)
+	if err != nil {
+		log.Println("ExportTransactions error:", err)
+		http.Error(w, "Failed to fetch transactions", http.StatusInternalServerError)
+		return
+	}
+
+	filename := "statement"
+	if !f.From.IsZero() {
+		filename += "-" + f.From.Format("20060102")
+	}
+	if !f.To.IsZero() {
+		filename += "-" + f.To.Format("20060102")
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "date", "type", "amount", "currency", "game", "round_id", "reason", "sub_wallet", "balance_after"})
+	cw.Write([]string{"", formatStatementDate(f.From), "", "", "", "", "", "opening_balance", "", opening.String()})
+	for _, t := range txs {
+		cw.Write([]string{
+			strconv.FormatInt(t.ID, 10),
+			t.CreatedAt.Format(time.RFC3339),
+			string(t.Type),
+			t.Amount.String(),
+			t.Currency,
+			t.Game,
+			t.RoundID,
+			t.Reason,
+			t.SubWallet,
+			t.BalanceAfter.String(),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Println("ExportTransactions write error:", err)
+	}
+}
+
+func formatStatementDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// RedeemWallet godoc
+// @Summary Redeem coins
+// @Description Files a redemption request for cash funds of a redeemable currency
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param request body object true "currency and amount"
+// @Success 201 {object} models.Redemption
+// @Failure 400 {string} string "Invalid request"
+// @Router /api/v1/wallet/redeem [post]
+func RedeemWallet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok || userID <= 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Currency string       `json:"currency"`
+		Amount   money.Amount `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	redemption, err := wallet.Redeem(userID, req.Currency, req.Amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, wallet.ErrUnknownCurrency):
+			http.Error(w, "Unknown currency", http.StatusBadRequest)
+		case errors.Is(err, wallet.ErrNotRedeemable):
+			http.Error(w, "Currency cannot be redeemed", http.StatusBadRequest)
+		case errors.Is(err, wallet.ErrBelowMinRedemption):
+			c, _ := wallet.LookupCurrency(req.Currency)
+			http.Error(w, fmt.Sprintf("Minimum redemption is %s %s", c.MinRedemption, c.Code), http.StatusBadRequest)
+		case errors.Is(err, wallet.ErrInsufficientFunds):
+			http.Error(w, "Insufficient balance", http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "User not found", http.StatusNotFound)
+		default:
+			log.Println("RedeemWallet error:", err)
+			http.Error(w, "Failed to redeem", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(redemption)
+}
@@ -0,0 +1,177 @@
+// Package jackpot keeps progressive jackpot pools in the database. A pool
+// starts from a seed, grows by a share of every stake placed on its game and
+// goes back to the seed when it is won. Each currency has its own pool.
+//
+// The pools are a record of what the house owes the next winner; the money
+// itself moves through the wallet like any other payout.
+//
+// The mystery jackpot tiers shared by several games are in mystery.go.
+package jackpot
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Pool is a jackpot and the settings it grows by. The settings can be
+// overridden with <Env>_SEED (an amount in coins), <Env>_SEED_<currency> for
+// a currency listed in Seeds, and <Env>_CONTRIBUTION (a percentage of the
+// stake).
+type Pool struct {
+	Name string
+	Env  string
+	// Seed is what the pool starts from and resets to after a win.
+	Seed money.Amount
+	// Seeds replaces Seed in the currencies it lists, so a redeemable
+	// currency does not start out owing as much as a free-play one.
+	Seeds map[string]money.Amount
+	// Contribution is the share of every stake added to the pool, in basis
+	// points.
+	Contribution int64
+}
+
+// SeedAmount is the configured seed of the pool in a currency.
+func (p Pool) SeedAmount(currency string) money.Amount {
+	if c, err := wallet.LookupCurrency(currency); err == nil {
+		if seed, ok := p.Seeds[c.Code]; ok {
+			return envAmount(p.Env+"_SEED_"+c.Code, seed)
+		}
+	}
+	return envAmount(p.Env+"_SEED", p.Seed)
+}
+
+// ContributionRate is the configured contribution of the pool, in basis
+// points of the stake.
+func (p Pool) ContributionRate() int64 {
+	return envRate(p.Env+"_CONTRIBUTION", p.Contribution)
+}
+
+// envAmount reads an amount in coins from the environment.
+func envAmount(name string, def money.Amount) money.Amount {
+	if v := os.Getenv(name); v != "" {
+		if a, err := money.Parse(v); err == nil && a > 0 {
+			return a
+		}
+		log.Printf("Invalid %s, using default: %s\n", name, v)
+	}
+	return def
+}
+
+// envRate reads a percentage from the environment, in basis points.
+func envRate(name string, def int64) int64 {
+	if v := os.Getenv(name); v != "" {
+		if pct, err := strconv.ParseFloat(v, 64); err == nil && pct >= 0 && pct <= 100 {
+			return int64(math.Round(pct * 100))
+		}
+		log.Printf("Invalid %s, using default: %s\n", name, v)
+	}
+	return def
+}
+
+// share is what a stake adds to the pool.
+func (p Pool) share(stake money.Amount) money.Amount {
+	return stake.MulFrac(p.ContributionRate(), 10000)
+}
+
+// ContributeTx adds the pool's share of stake and returns the pool
+// afterwards.
+func (p Pool) ContributeTx(tx *sql.Tx, currency string, stake money.Amount) (money.Amount, error) {
+	currency, err := p.ensureTx(tx, currency)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(`
+		UPDATE jackpot_pools SET amount = amount + ?, updated_at = ?
+		WHERE pool = ? AND currency = ?`, p.share(stake), time.Now(), p.Name, currency)
+	if err != nil {
+		return 0, err
+	}
+	var amount money.Amount
+	err = tx.QueryRow("SELECT amount FROM jackpot_pools WHERE pool = ? AND currency = ?", p.Name, currency).Scan(&amount)
+	return amount, err
+}
+
+// AwardTx pays the pool to a winner: it returns the amount won, records the
+// win and resets the pool to its seed, all inside tx. The caller credits the
+// amount to the player in the same transaction.
+func (p Pool) AwardTx(tx *sql.Tx, userID int, currency, roundID string) (money.Amount, error) {
+	currency, err := p.ensureTx(tx, currency)
+	if err != nil {
+		return 0, err
+	}
+	var amount money.Amount
+	err = tx.QueryRow(`
+		SELECT amount FROM jackpot_pools
+		WHERE pool = ? AND currency = ?
+		FOR UPDATE`, p.Name, currency).Scan(&amount)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		UPDATE jackpot_pools SET amount = ?, updated_at = ?
+		WHERE pool = ? AND currency = ?`, p.SeedAmount(currency), now, p.Name, currency); err != nil {
+		return 0, err
+	}
+	var round sql.NullString
+	if roundID != "" {
+		round = sql.NullString{String: roundID, Valid: true}
+	}
+	_, err = tx.Exec(`
+		INSERT INTO jackpot_wins (pool, currency, user_id, round_id, amount, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, p.Name, currency, userID, round, amount, now)
+	return amount, err
+}
+
+// Info returns the pool in a currency and its last win.
+func (p Pool) Info(currency string) (models.JackpotInfo, error) {
+	c, err := wallet.LookupCurrency(currency)
+	if err != nil {
+		return models.JackpotInfo{}, err
+	}
+	info := models.JackpotInfo{Pool: p.Name, Currency: c.Code, Amount: p.SeedAmount(c.Code)}
+
+	err = database.DB.QueryRow("SELECT amount FROM jackpot_pools WHERE pool = ? AND currency = ?", p.Name, c.Code).Scan(&info.Amount)
+	if err != nil && err != sql.ErrNoRows {
+		return info, err
+	}
+
+	var at time.Time
+	err = database.DB.QueryRow(`
+		SELECT u.username, w.amount, w.created_at
+		FROM jackpot_wins w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.pool = ? AND w.currency = ?
+		ORDER BY w.id DESC
+		LIMIT 1`, p.Name, c.Code).Scan(&info.LastWinner, &info.LastWinAmount, &at)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return info, err
+	default:
+		info.LastWinTime = &at
+	}
+	return info, nil
+}
+
+// ensureTx creates the pool at its seed the first time it is played in a
+// currency, and returns the currency's code.
+func (p Pool) ensureTx(tx *sql.Tx, currency string) (string, error) {
+	c, err := wallet.LookupCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+	_, err = tx.Exec(`
+		INSERT IGNORE INTO jackpot_pools (pool, currency, amount, updated_at)
+		VALUES (?, ?, ?, ?)`, p.Name, c.Code, p.SeedAmount(c.Code), time.Now())
+	return c.Code, err
+}
@@ -0,0 +1,89 @@
+package jackpot
+
+import (
+	"casino-hub/backend/money"
+	"testing"
+)
+
+func TestPoolSeedAmount(t *testing.T) {
+	pool := Pool{
+		Name:  "test",
+		Env:   "TEST_JACKPOT",
+		Seed:  money.Coins(100000),
+		Seeds: map[string]money.Amount{"SC": money.Coins(1000)},
+	}
+	tests := []struct {
+		name     string
+		env      map[string]string
+		currency string
+		want     money.Amount
+	}{
+		{"default seed", nil, "GC", money.Coins(100000)},
+		{"default currency", nil, "", money.Coins(100000)},
+		{"currency seed", nil, "SC", money.Coins(1000)},
+		{"unknown currency", nil, "XX", money.Coins(100000)},
+		{"seed from env", map[string]string{"TEST_JACKPOT_SEED": "250000"}, "GC", money.Coins(250000)},
+		// The shared setting leaves a currency with a seed of its own alone.
+		{"seed from env in a currency with its own", map[string]string{"TEST_JACKPOT_SEED": "250000"}, "SC", money.Coins(1000)},
+		{"currency seed from env", map[string]string{"TEST_JACKPOT_SEED_SC": "2500.50"}, "SC", money.Coins(2500) + 50},
+		{"invalid currency seed", map[string]string{"TEST_JACKPOT_SEED_SC": "-1"}, "SC", money.Coins(1000)},
+		{"zero seed", map[string]string{"TEST_JACKPOT_SEED": "0"}, "GC", money.Coins(100000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := pool.SeedAmount(tt.currency); got != tt.want {
+				t.Errorf("SeedAmount(%q) = %s, want %s", tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolContributionRate(t *testing.T) {
+	pool := Pool{Env: "TEST_JACKPOT", Contribution: 100}
+	tests := []struct {
+		env  string
+		want int64
+	}{
+		{"", 100},
+		{"2.5", 250},
+		{"0", 0},
+		{"100", 10000},
+		{"100.5", 100},
+		{"-1", 100},
+		{"lots", 100},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_JACKPOT_CONTRIBUTION", tt.env)
+		if got := pool.ContributionRate(); got != tt.want {
+			t.Errorf("ContributionRate with %q = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestPoolShare(t *testing.T) {
+	pool := Pool{Env: "TEST_JACKPOT", Contribution: 100}
+	tests := []struct {
+		rate  string
+		stake money.Amount
+		want  money.Amount
+	}{
+		{"", money.Coins(10), 10},
+		{"", money.Coins(1000), money.Coins(10)},
+		// 1% of 0.50 is half a cent, rounded to even.
+		{"", 50, 0},
+		{"", 150, 2},
+		{"", 250, 2},
+		{"2.5", money.Coins(10), 25},
+		{"0", money.Coins(1000), 0},
+		{"100", money.Coins(10), money.Coins(10)},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_JACKPOT_CONTRIBUTION", tt.rate)
+		if got := pool.share(tt.stake); got != tt.want {
+			t.Errorf("share of %s at %q = %s, want %s", tt.stake, tt.rate, got, tt.want)
+		}
+	}
+}
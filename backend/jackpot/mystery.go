@@ -0,0 +1,210 @@
+package jackpot
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"math/rand"
+	"time"
+)
+
+// Tier is a level of the mystery jackpot shared by the MysteryGames. Every
+// qualifying wager adds to every tier. When a tier is reset it picks a
+// hidden drop point between its seed and MustDropBy, and the wager that
+// takes it there wins it, so the winner is a random qualifying bettor and
+// the tier never grows past MustDropBy.
+//
+// The settings can be overridden with <Env>_SEED, <Env>_MUST_DROP_BY (amounts
+// in coins) and <Env>_CONTRIBUTION (a percentage of the stake).
+type Tier struct {
+	Name       string
+	Env        string
+	Seed       money.Amount
+	MustDropBy money.Amount
+	// Contribution is the share of every stake added to the tier, in basis
+	// points.
+	Contribution int64
+}
+
+// Tiers are the mystery jackpot tiers, smallest first.
+var Tiers = []Tier{
+	{Name: "mini", Env: "MYSTERY_MINI", Seed: money.Coins(50), MustDropBy: money.Coins(250), Contribution: 50},
+	{Name: "minor", Env: "MYSTERY_MINOR", Seed: money.Coins(500), MustDropBy: money.Coins(2500), Contribution: 30},
+	{Name: "major", Env: "MYSTERY_MAJOR", Seed: money.Coins(5000), MustDropBy: money.Coins(25000), Contribution: 15},
+	{Name: "grand", Env: "MYSTERY_GRAND", Seed: money.Coins(50000), MustDropBy: money.Coins(250000), Contribution: 5},
+}
+
+// MysteryGames are the games whose wagers take part in the mystery jackpot.
+var MysteryGames = map[string]bool{
+	"Slot":             true,
+	"Progressive Slot": true,
+	"Keno":             true,
+	"Roulette":         true,
+}
+
+// MysteryMinStake is the smallest wager that takes part in the mystery
+// jackpot, set with MYSTERY_JACKPOT_MIN_STAKE.
+func MysteryMinStake() money.Amount {
+	return envAmount("MYSTERY_JACKPOT_MIN_STAKE", money.Coins(1))
+}
+
+// Qualifies reports whether a wager takes part in the mystery jackpot.
+func Qualifies(game string, stake money.Amount) bool {
+	return MysteryGames[game] && stake >= MysteryMinStake()
+}
+
+// SeedAmount is the configured seed of the tier.
+func (t Tier) SeedAmount() money.Amount {
+	return envAmount(t.Env+"_SEED", t.Seed)
+}
+
+// MustDropByAmount is the configured cap of the tier. It is never below the
+// seed.
+func (t Tier) MustDropByAmount() money.Amount {
+	return max(envAmount(t.Env+"_MUST_DROP_BY", t.MustDropBy), t.SeedAmount())
+}
+
+// ContributionRate is the configured contribution of the tier, in basis
+// points of the stake.
+func (t Tier) ContributionRate() int64 {
+	return envRate(t.Env+"_CONTRIBUTION", t.Contribution)
+}
+
+// dropPoint picks where a tier starting at seed will drop.
+func (t Tier) dropPoint(seed money.Amount) money.Amount {
+	top := t.MustDropByAmount()
+	if top <= seed {
+		return seed
+	}
+	return seed + money.Amount(rand.Int63n(int64(top-seed)+1))
+}
+
+// share is what a stake adds to the tier.
+func (t Tier) share(stake money.Amount) money.Amount {
+	return stake.MulFrac(t.ContributionRate(), 10000)
+}
+
+// drops reports whether a tier that has grown to amount drops at dropAt. The
+// cap is checked too in case it was lowered after the drop point was picked.
+func (t Tier) drops(amount, dropAt money.Amount) bool {
+	return amount >= dropAt || amount >= t.MustDropByAmount()
+}
+
+// Drop runs a settled wager through the mystery jackpot. It adds the wager's
+// contribution to every tier and pays each tier that drops to the player,
+// under the wager's round, in one transaction of its own. It returns the
+// tiers won; wagers that do not qualify are ignored.
+func Drop(userID int, game, currency, roundID string, stake money.Amount) ([]models.MysteryJackpotWin, error) {
+	if !Qualifies(game, stake) {
+		return nil, nil
+	}
+	c, err := wallet.LookupCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Tiers are locked in the same order by every wager.
+	var wins []models.MysteryJackpotWin
+	for _, t := range Tiers {
+		amount, dropped, err := t.contributeTx(tx, c.Code, stake)
+		if err != nil {
+			return nil, err
+		}
+		if !dropped {
+			continue
+		}
+		if _, err := wallet.PayoutTx(tx, userID, game, roundID, c.Code, amount); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		_, err = tx.Exec(`
+			INSERT INTO mystery_jackpot_wins (tier, currency, user_id, game, round_id, amount, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`, t.Name, c.Code, userID, game, roundID, amount, now)
+		if err != nil {
+			return nil, err
+		}
+		wins = append(wins, models.MysteryJackpotWin{Tier: t.Name, Currency: c.Code, Game: game, Amount: amount, WonAt: now})
+	}
+	return wins, tx.Commit()
+}
+
+// contributeTx adds the tier's share of stake. If that takes the tier to its
+// drop point it resets the tier and reports the amount it dropped at.
+func (t Tier) contributeTx(tx *sql.Tx, currency string, stake money.Amount) (money.Amount, bool, error) {
+	now := time.Now()
+	seed := t.SeedAmount()
+	_, err := tx.Exec(`
+		INSERT IGNORE INTO mystery_jackpots (tier, currency, amount, drop_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, t.Name, currency, seed, t.dropPoint(seed), now)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var amount, dropAt money.Amount
+	err = tx.QueryRow(`
+		SELECT amount, drop_at FROM mystery_jackpots
+		WHERE tier = ? AND currency = ?
+		FOR UPDATE`, t.Name, currency).Scan(&amount, &dropAt)
+	if err != nil {
+		return 0, false, err
+	}
+	amount += t.share(stake)
+
+	if !t.drops(amount, dropAt) {
+		_, err := tx.Exec(`
+			UPDATE mystery_jackpots SET amount = ?, updated_at = ?
+			WHERE tier = ? AND currency = ?`, amount, now, t.Name, currency)
+		return amount, false, err
+	}
+	_, err = tx.Exec(`
+		UPDATE mystery_jackpots SET amount = ?, drop_at = ?, updated_at = ?
+		WHERE tier = ? AND currency = ?`, seed, t.dropPoint(seed), now, t.Name, currency)
+	return amount, true, err
+}
+
+// Mystery returns the mystery jackpot tiers in a currency and its last
+// winners, newest first.
+func Mystery(currency string, limit int) (models.MysteryJackpots, error) {
+	c, err := wallet.LookupCurrency(currency)
+	if err != nil {
+		return models.MysteryJackpots{}, err
+	}
+	info := models.MysteryJackpots{Currency: c.Code, RecentWinners: []models.MysteryJackpotWin{}}
+
+	for _, t := range Tiers {
+		tier := models.MysteryJackpotTier{Tier: t.Name, Amount: t.SeedAmount(), MustDropBy: t.MustDropByAmount()}
+		err := database.DB.QueryRow("SELECT amount FROM mystery_jackpots WHERE tier = ? AND currency = ?", t.Name, c.Code).Scan(&tier.Amount)
+		if err != nil && err != sql.ErrNoRows {
+			return info, err
+		}
+		info.Tiers = append(info.Tiers, tier)
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT w.tier, w.game, u.username, w.amount, w.created_at
+		FROM mystery_jackpot_wins w
+		JOIN users u ON u.id = w.user_id
+		WHERE w.currency = ?
+		ORDER BY w.id DESC
+		LIMIT ?`, c.Code, limit)
+	if err != nil {
+		return info, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		win := models.MysteryJackpotWin{Currency: c.Code}
+		if err := rows.Scan(&win.Tier, &win.Game, &win.Winner, &win.Amount, &win.WonAt); err != nil {
+			return info, err
+		}
+		info.RecentWinners = append(info.RecentWinners, win)
+	}
+	return info, rows.Err()
+}
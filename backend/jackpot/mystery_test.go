@@ -0,0 +1,135 @@
+package jackpot
+
+import (
+	"casino-hub/backend/money"
+	"testing"
+)
+
+func TestTiers(t *testing.T) {
+	for i, tier := range Tiers {
+		if tier.Seed <= 0 || tier.MustDropBy <= tier.Seed {
+			t.Errorf("%s seeds at %s and must drop by %s", tier.Name, tier.Seed, tier.MustDropBy)
+		}
+		if i > 0 && tier.MustDropBy <= Tiers[i-1].MustDropBy {
+			t.Errorf("%s is not bigger than %s", tier.Name, Tiers[i-1].Name)
+		}
+	}
+}
+
+func TestDropPoint(t *testing.T) {
+	tier := Tier{Env: "TEST_MYSTERY", MustDropBy: 1010}
+	seen := map[money.Amount]bool{}
+	for i := 0; i < 2000; i++ {
+		p := tier.dropPoint(1000)
+		if p < 1000 || p > 1010 {
+			t.Fatalf("drop point %s outside 10.00 to 10.10", p)
+		}
+		seen[p] = true
+	}
+	// Both ends of the range can be picked.
+	if !seen[1000] || !seen[1010] || len(seen) != 11 {
+		t.Errorf("picked %d of the 11 drop points", len(seen))
+	}
+
+	for _, seed := range []money.Amount{1010, 2000} {
+		if p := tier.dropPoint(seed); p != seed {
+			t.Errorf("dropPoint(%s) with a cap of %s = %s, want the seed", seed, tier.MustDropBy, p)
+		}
+	}
+}
+
+func TestTierSettings(t *testing.T) {
+	tier := Tier{Env: "TEST_MYSTERY", Seed: money.Coins(50), MustDropBy: money.Coins(250), Contribution: 50}
+	tests := []struct {
+		name       string
+		env        map[string]string
+		seed       money.Amount
+		mustDropBy money.Amount
+	}{
+		{"defaults", nil, money.Coins(50), money.Coins(250)},
+		{"seed from env", map[string]string{"TEST_MYSTERY_SEED": "75"}, money.Coins(75), money.Coins(250)},
+		{"cap from env", map[string]string{"TEST_MYSTERY_MUST_DROP_BY": "100.50"}, money.Coins(50), money.Coins(100) + 50},
+		// The cap is never below the seed.
+		{"cap below the seed", map[string]string{"TEST_MYSTERY_MUST_DROP_BY": "20"}, money.Coins(50), money.Coins(50)},
+		{"seed above the cap", map[string]string{"TEST_MYSTERY_SEED": "300"}, money.Coins(300), money.Coins(300)},
+		{"invalid values", map[string]string{"TEST_MYSTERY_SEED": "-5", "TEST_MYSTERY_MUST_DROP_BY": "lots"}, money.Coins(50), money.Coins(250)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := tier.SeedAmount(); got != tt.seed {
+				t.Errorf("SeedAmount = %s, want %s", got, tt.seed)
+			}
+			if got := tier.MustDropByAmount(); got != tt.mustDropBy {
+				t.Errorf("MustDropByAmount = %s, want %s", got, tt.mustDropBy)
+			}
+		})
+	}
+}
+
+func TestTierDrops(t *testing.T) {
+	tier := Tier{Env: "TEST_MYSTERY", Seed: money.Coins(50), MustDropBy: money.Coins(250)}
+	tests := []struct {
+		name           string
+		amount, dropAt money.Amount
+		want           bool
+	}{
+		{"below the drop point", money.Coins(100), money.Coins(120), false},
+		{"at the drop point", money.Coins(120), money.Coins(120), true},
+		{"past the drop point", money.Coins(121), money.Coins(120), true},
+		// A drop point picked before the cap was lowered.
+		{"at the cap", money.Coins(250), money.Coins(300), true},
+		{"below the cap", money.Coins(249), money.Coins(300), false},
+	}
+	for _, tt := range tests {
+		if got := tier.drops(tt.amount, tt.dropAt); got != tt.want {
+			t.Errorf("%s: drops(%s, %s) = %v, want %v", tt.name, tt.amount, tt.dropAt, got, tt.want)
+		}
+	}
+}
+
+func TestTierShare(t *testing.T) {
+	tier := Tier{Env: "TEST_MYSTERY", Contribution: 50}
+	tests := []struct {
+		rate  string
+		stake money.Amount
+		want  money.Amount
+	}{
+		{"", money.Coins(10), 5},
+		{"", money.Coins(1), 0},
+		{"", 300, 2},
+		{"1.5", money.Coins(10), 15},
+		{"0", money.Coins(10), 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_MYSTERY_CONTRIBUTION", tt.rate)
+		if got := tier.share(tt.stake); got != tt.want {
+			t.Errorf("share of %s at %q = %s, want %s", tt.stake, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestQualifies(t *testing.T) {
+	tests := []struct {
+		name     string
+		minStake string
+		game     string
+		stake    money.Amount
+		want     bool
+	}{
+		{"slot", "", "Slot", money.Coins(1), true},
+		{"keno", "", "Keno", money.Coins(5), true},
+		{"below the minimum", "", "Roulette", 99, false},
+		{"game outside the network", "", "Blackjack", money.Coins(100), false},
+		{"raised minimum", "5", "Progressive Slot", money.Coins(4), false},
+		{"at the raised minimum", "5", "Progressive Slot", money.Coins(5), true},
+	}
+	for _, tt := range tests {
+		t.Setenv("MYSTERY_JACKPOT_MIN_STAKE", tt.minStake)
+		if got := Qualifies(tt.game, tt.stake); got != tt.want {
+			t.Errorf("%s: Qualifies(%q, %s) = %v, want %v", tt.name, tt.game, tt.stake, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package keno draws Keno numbers and pays tickets. Games are either played
+// instantly, with a private draw per ticket, or on the shared live draws the
+// server runs on a schedule (see live.go).
+package keno
+
+import (
+	"casino-hub/backend/jackpot"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// Numbers is how many numbers the board has, 1 to Numbers.
+	Numbers = 80
+	// DrawSize is how many numbers a draw picks.
+	DrawSize = 20
+	// MaxPicks is the most numbers a ticket can pick.
+	MaxPicks = 10
+)
+
+// Jackpot is won by hitting 10 out of 10. It is configured with
+// KENO_JACKPOT_SEED, KENO_JACKPOT_SEED_SC and KENO_JACKPOT_CONTRIBUTION.
+var Jackpot = jackpot.Pool{
+	Name:         "keno",
+	Env:          "KENO_JACKPOT",
+	Seed:         money.Coins(50000),
+	Seeds:        map[string]money.Amount{wallet.SweepsCoins: money.Coins(500)},
+	Contribution: 100,
+}
+
+// Draw picks DrawSize different numbers.
+func Draw() []int {
+	available := make([]int, Numbers)
+	for i := range available {
+		available[i] = i + 1
+	}
+	drawn := make([]int, 0, DrawSize)
+	for range DrawSize {
+		idx := rand.Intn(len(available))
+		drawn = append(drawn, available[idx])
+		available = append(available[:idx], available[idx+1:]...)
+	}
+	return drawn
+}
+
+// CountHits counts the picks that were drawn.
+func CountHits(picks, drawn []int) int {
+	hits := 0
+	for _, num := range picks {
+		for _, d := range drawn {
+			if num == d {
+				hits++
+				break
+			}
+		}
+	}
+	return hits
+}
+
+// Payout is what a ticket of picks numbers wins with hits of them drawn,
+// stake included, not counting the jackpot.
+func Payout(bet money.Amount, picks, hits int) money.Amount {
+	if table, ok := models.PayoutTable[picks]; ok && hits < len(table) {
+		return bet.Times(int64(table[hits]))
+	}
+	return 0
+}
+
+// IsJackpot reports whether a ticket wins the jackpot.
+func IsJackpot(picks, hits int) bool {
+	return picks == MaxPicks && hits == MaxPicks
+}
+
+var (
+	ErrPickCount     = fmt.Errorf("keno: pick 1-%d numbers", MaxPicks)
+	ErrPickRange     = fmt.Errorf("keno: numbers must be between 1 and %d", Numbers)
+	ErrDuplicatePick = errors.New("keno: each number can only be picked once")
+)
+
+// ValidatePicks checks a ticket's numbers: 1 to MaxPicks different numbers
+// on the board.
+func ValidatePicks(picks []int) error {
+	if len(picks) == 0 || len(picks) > MaxPicks {
+		return ErrPickCount
+	}
+	seen := map[int]bool{}
+	for _, n := range picks {
+		if n < 1 || n > Numbers {
+			return ErrPickRange
+		}
+		if seen[n] {
+			return ErrDuplicatePick
+		}
+		seen[n] = true
+	}
+	return nil
+}
+
+// QuickPick picks n different numbers at random, in ascending order.
+func QuickPick(n int) ([]int, error) {
+	if n <= 0 || n > MaxPicks {
+		return nil, ErrPickCount
+	}
+	picks := rand.Perm(Numbers)[:n]
+	for i := range picks {
+		picks[i]++
+	}
+	sort.Ints(picks)
+	return picks, nil
+}
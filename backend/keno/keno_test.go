@@ -0,0 +1,173 @@
+package keno
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"errors"
+	"testing"
+)
+
+func TestValidatePicks(t *testing.T) {
+	tests := []struct {
+		name  string
+		picks []int
+		want  error
+	}{
+		{"one number", []int{40}, nil},
+		{"ten numbers", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 80}, nil},
+		{"any order", []int{80, 1, 33}, nil},
+		{"no numbers", nil, ErrPickCount},
+		{"eleven numbers", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, ErrPickCount},
+		{"zero", []int{0, 5}, ErrPickRange},
+		{"above the board", []int{5, 81}, ErrPickRange},
+		{"negative", []int{-3}, ErrPickRange},
+		{"duplicate", []int{7, 12, 7}, ErrDuplicatePick},
+		{"eleven duplicates", []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, ErrPickCount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePicks(tt.picks); !errors.Is(err, tt.want) {
+				t.Errorf("ValidatePicks(%v) = %v, want %v", tt.picks, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuickPick(t *testing.T) {
+	for n := 1; n <= MaxPicks; n++ {
+		// Enough tickets to catch a duplicate or an off-by-one at the edges.
+		for i := 0; i < 200; i++ {
+			picks, err := QuickPick(n)
+			if err != nil {
+				t.Fatalf("QuickPick(%d): %v", n, err)
+			}
+			if len(picks) != n {
+				t.Fatalf("QuickPick(%d) picked %d numbers", n, len(picks))
+			}
+			if err := ValidatePicks(picks); err != nil {
+				t.Fatalf("QuickPick(%d) = %v: %v", n, picks, err)
+			}
+			for j := 1; j < len(picks); j++ {
+				if picks[j] <= picks[j-1] {
+					t.Fatalf("QuickPick(%d) = %v, not in ascending order", n, picks)
+				}
+			}
+		}
+	}
+
+	for _, n := range []int{-1, 0, MaxPicks + 1} {
+		if _, err := QuickPick(n); !errors.Is(err, ErrPickCount) {
+			t.Errorf("QuickPick(%d) err = %v, want %v", n, err, ErrPickCount)
+		}
+	}
+}
+
+func TestQuickPickCoversTheBoard(t *testing.T) {
+	seen := map[int]bool{}
+	for i := 0; i < 1000 && len(seen) < Numbers; i++ {
+		picks, _ := QuickPick(MaxPicks)
+		for _, n := range picks {
+			seen[n] = true
+		}
+	}
+	if !seen[1] || !seen[Numbers] || len(seen) != Numbers {
+		t.Errorf("quick picks covered %d of the %d numbers", len(seen), Numbers)
+	}
+}
+
+func TestPayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		bet         money.Amount
+		picks, hits int
+		want        money.Amount
+	}{
+		{"one of one", 100, 1, 1, 300},
+		{"none of one", 100, 1, 0, 0},
+		{"stake back", 100, 3, 1, 100},
+		{"five of five", money.Coins(2), 5, 5, money.Coins(774)},
+		{"ten of ten", 100, 10, 10, money.Coins(100000)},
+		{"nine of ten", 100, 10, 9, money.Coins(1800)},
+		{"three of ten", 100, 10, 3, 0},
+		{"more hits than picks", 100, 2, 3, 0},
+		{"no picks", 100, 0, 0, 0},
+		{"too many picks", 100, 11, 11, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Payout(tt.bet, tt.picks, tt.hits); got != tt.want {
+				t.Errorf("Payout(%s, %d, %d) = %s, want %s", tt.bet, tt.picks, tt.hits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPayoutTable(t *testing.T) {
+	for picks := 1; picks <= MaxPicks; picks++ {
+		table, ok := models.PayoutTable[picks]
+		if !ok {
+			t.Errorf("no payouts for %d picks", picks)
+			continue
+		}
+		if len(table) != picks+1 {
+			t.Errorf("%d picks pay for %d hit counts, want %d", picks, len(table), picks+1)
+		}
+		for hits := 1; hits < len(table); hits++ {
+			if table[hits] < table[hits-1] {
+				t.Errorf("%d picks pay less for %d hits than for %d", picks, hits, hits-1)
+			}
+		}
+	}
+}
+
+func TestIsJackpot(t *testing.T) {
+	tests := []struct {
+		picks, hits int
+		want        bool
+	}{
+		{10, 10, true},
+		{10, 9, false},
+		{9, 9, false},
+		{1, 1, false},
+	}
+	for _, tt := range tests {
+		if got := IsJackpot(tt.picks, tt.hits); got != tt.want {
+			t.Errorf("IsJackpot(%d, %d) = %v, want %v", tt.picks, tt.hits, got, tt.want)
+		}
+	}
+}
+
+func TestCountHits(t *testing.T) {
+	drawn := []int{3, 7, 12, 19, 25, 33, 40, 41, 48, 50, 55, 60, 62, 66, 70, 72, 75, 77, 79, 80}
+	tests := []struct {
+		picks []int
+		want  int
+	}{
+		{[]int{3}, 1},
+		{[]int{4}, 0},
+		{[]int{80, 1, 7, 2}, 2},
+		{[]int{3, 7, 12, 19, 25, 33, 40, 41, 48, 50}, 10},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := CountHits(tt.picks, drawn); got != tt.want {
+			t.Errorf("CountHits(%v) = %d, want %d", tt.picks, got, tt.want)
+		}
+	}
+}
+
+func TestDraw(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		drawn := Draw()
+		if len(drawn) != DrawSize {
+			t.Fatalf("drew %d numbers, want %d", len(drawn), DrawSize)
+		}
+		seen := map[int]bool{}
+		for _, n := range drawn {
+			if n < 1 || n > Numbers || seen[n] {
+				t.Fatalf("draw %v has %d out of range or twice", drawn, n)
+			}
+			seen[n] = true
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package keno
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+// Live draw and ticket statuses.
+const (
+	DrawOpen  = "open"
+	DrawDrawn = "drawn"
+
+	TicketPending = "pending"
+	TicketSettled = "settled"
+	// TicketVoid is a ticket that could not be settled and had its bet
+	// refunded.
+	TicketVoid = "void"
+)
+
+const (
+	defaultDrawInterval = 5 * time.Minute
+	// MaxDraws is the most consecutive draws a ticket can be bought for.
+	MaxDraws = 20
+)
+
+var ErrNoOpenDraws = errors.New("keno: draws are not open for tickets")
+
+// DrawInterval is the time between live draws, set with KENO_DRAW_INTERVAL
+// (a Go duration such as "5m").
+func DrawInterval() time.Duration {
+	if v := os.Getenv("KENO_DRAW_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Minute {
+			return d
+		}
+		log.Println("Invalid KENO_DRAW_INTERVAL, using default:", v)
+	}
+	return defaultDrawInterval
+}
+
+// nextDrawAt is the first scheduled draw after now. Draws fall on multiples
+// of the interval, so every server agrees on the schedule.
+func nextDrawAt(now time.Time) time.Time {
+	interval := DrawInterval()
+	return now.Truncate(interval).Add(interval)
+}
+
+// LockDrawsTx schedules the next n draws if they are not already and returns
+// them, locked against being drawn until tx ends. A ticket purchase calls it
+// before taking the stake, so it locks the draws first, the same order draws
+// are settled in.
+func LockDrawsTx(tx *sql.Tx, now time.Time, n int) ([]models.KenoDraw, error) {
+	first := nextDrawAt(now)
+	for i := range n {
+		_, err := tx.Exec("INSERT IGNORE INTO keno_draws (draw_at, status) VALUES (?, ?)", first.Add(time.Duration(i)*DrawInterval()), DrawOpen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, draw_at, status
+		FROM keno_draws
+		WHERE draw_at >= ? AND status = ?
+		ORDER BY draw_at
+		LIMIT ?
+		LOCK IN SHARE MODE`, first, DrawOpen, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var draws []models.KenoDraw
+	for rows.Next() {
+		var d models.KenoDraw
+		if err := rows.Scan(&d.ID, &d.DrawAt, &d.Status); err != nil {
+			return nil, err
+		}
+		draws = append(draws, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(draws) < n {
+		return nil, ErrNoOpenDraws
+	}
+	return draws, nil
+}
+
+// NextDraw returns the next live draw, scheduling it if needed.
+func NextDraw(now time.Time) (models.KenoDraw, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return models.KenoDraw{}, err
+	}
+	defer tx.Rollback()
+	draws, err := LockDrawsTx(tx, now, 1)
+	if err != nil {
+		return models.KenoDraw{}, err
+	}
+	return draws[0], tx.Commit()
+}
+
+// BuyTicketsTx stores a ticket for each of draws. Their stake, bet per draw,
+// has to be taken in tx under roundID.
+func BuyTicketsTx(tx *sql.Tx, userID int, currency, roundID string, picks []int, bet money.Amount, draws []models.KenoDraw) ([]models.KenoTicket, error) {
+	numbers, err := json.Marshal(picks)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tickets := make([]models.KenoTicket, 0, len(draws))
+	for _, d := range draws {
+		res, err := tx.Exec(`
+			INSERT INTO keno_tickets (round_id, user_id, draw_id, currency, numbers, bet, status, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, roundID, userID, d.ID, currency, numbers, bet, TicketPending, now)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, models.KenoTicket{
+			ID:              id,
+			DrawID:          d.ID,
+			DrawAt:          d.DrawAt,
+			SelectedNumbers: picks,
+			Bet:             bet,
+			Currency:        currency,
+			Status:          TicketPending,
+		})
+	}
+	return tickets, nil
+}
+
+// RunDueDraws draws every live draw whose time has come and settles the
+// tickets of drawn draws. A draw or ticket that fails is logged and left for
+// the next run, or refunded if it cannot be settled, so it never holds up
+// the others. It returns how many draws it ran.
+func RunDueDraws(now time.Time) (int, error) {
+	rows, err := database.DB.Query(`
+		SELECT id FROM keno_draws
+		WHERE status = ? AND draw_at <= ?
+		ORDER BY draw_at`, DrawOpen, now)
+	if err != nil {
+		return 0, err
+	}
+	var due []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	ran := 0
+	for _, id := range due {
+		if err := runDraw(id); err != nil {
+			log.Printf("keno: drawing draw %d: %v", id, err)
+			continue
+		}
+		ran++
+	}
+	return ran, settlePendingTickets()
+}
+
+// runDraw picks the numbers of one live draw. Its tickets are settled
+// afterwards, each on its own. A draw another server already ran is
+// skipped.
+func runDraw(id int64) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM keno_draws WHERE id = ? FOR UPDATE", id).Scan(&status); err != nil {
+		return err
+	}
+	if status != DrawOpen {
+		return nil
+	}
+
+	numbers, err := json.Marshal(Draw())
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE keno_draws SET status = ?, numbers = ?, drawn_at = ? WHERE id = ?", DrawDrawn, numbers, time.Now(), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// settleBatch is how many tickets settlePendingTickets looks at per run.
+const settleBatch = 500
+
+// settlePendingTickets settles the pending tickets of drawn draws one by
+// one. A ticket that fails to settle is refunded and voided instead.
+func settlePendingTickets() error {
+	rows, err := database.DB.Query(`
+		SELECT t.id FROM keno_tickets t
+		JOIN keno_draws d ON d.id = t.draw_id
+		WHERE t.status = ? AND d.status = ?
+		ORDER BY t.id
+		LIMIT ?`, TicketPending, DrawDrawn, settleBatch)
+	if err != nil {
+		return err
+	}
+	var pending []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range pending {
+		err := settleTicket(id)
+		if err == nil {
+			continue
+		}
+		log.Printf("keno: settling ticket %d: %v", id, err)
+		if err := voidTicket(id); err != nil {
+			log.Printf("keno: voiding ticket %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+type pendingTicket struct {
+	userID   int
+	roundID  string
+	currency string
+	picks    []int
+	bet      money.Amount
+	drawn    []int
+}
+
+// lockPendingTicket locks a ticket that is still waiting to be settled,
+// along with the numbers of its draw. ok is false if it has been settled
+// or voided meanwhile.
+func lockPendingTicket(tx *sql.Tx, id int64) (t pendingTicket, ok bool, err error) {
+	var status string
+	var picks, drawn []byte
+	err = tx.QueryRow(`
+		SELECT t.user_id, t.round_id, t.currency, t.numbers, t.bet, t.status, d.numbers
+		FROM keno_tickets t
+		JOIN keno_draws d ON d.id = t.draw_id
+		WHERE t.id = ?
+		FOR UPDATE`, id).Scan(&t.userID, &t.roundID, &t.currency, &picks, &t.bet, &status, &drawn)
+	if err != nil || status != TicketPending {
+		return t, false, err
+	}
+	if err := json.Unmarshal(picks, &t.picks); err != nil {
+		return t, false, err
+	}
+	if err := json.Unmarshal(drawn, &t.drawn); err != nil {
+		return t, false, err
+	}
+	return t, true, nil
+}
+
+// settleTicket pays one ticket against its draw's numbers.
+func settleTicket(id int64) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, ok, err := lockPendingTicket(tx, id)
+	if err != nil || !ok {
+		return err
+	}
+
+	hits := CountHits(t.picks, t.drawn)
+	payout := Payout(t.bet, len(t.picks), hits)
+	jackpotWon := IsJackpot(len(t.picks), hits)
+	if jackpotWon {
+		won, err := Jackpot.AwardTx(tx, t.userID, t.currency, t.roundID)
+		if err != nil {
+			return err
+		}
+		payout += won
+	}
+	if _, err := wallet.PayoutTx(tx, t.userID, "Keno", t.roundID, t.currency, payout); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE keno_tickets SET status = ?, hits = ?, payout = ?, jackpot_won = ?, settled_at = ?
+		WHERE id = ?`, TicketSettled, hits, payout, jackpotWon, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// voidTicket gives a ticket that could not be settled its bet back and
+// takes it out of play.
+func voidTicket(id int64) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var roundID, currency, status string
+	var bet money.Amount
+	err = tx.QueryRow("SELECT user_id, round_id, currency, bet, status FROM keno_tickets WHERE id = ? FOR UPDATE", id).
+		Scan(&userID, &roundID, &currency, &bet, &status)
+	if err != nil {
+		return err
+	}
+	if status != TicketPending {
+		return nil
+	}
+	if _, err := wallet.RefundTx(tx, userID, "Keno", roundID, currency, bet); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE keno_tickets SET status = ?, settled_at = ? WHERE id = ?", TicketVoid, time.Now(), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// History returns the last live draws that have been drawn, newest first.
+func History(limit int) ([]models.KenoDraw, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, draw_at, status, numbers, drawn_at
+		FROM keno_draws
+		WHERE status = ?
+		ORDER BY draw_at DESC
+		LIMIT ?`, DrawDrawn, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	draws := []models.KenoDraw{}
+	for rows.Next() {
+		var d models.KenoDraw
+		var numbers []byte
+		var drawnAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.DrawAt, &d.Status, &numbers, &drawnAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(numbers, &d.Numbers); err != nil {
+			return nil, err
+		}
+		if drawnAt.Valid {
+			d.DrawnAt = &drawnAt.Time
+		}
+		draws = append(draws, d)
+	}
+	return draws, rows.Err()
+}
+
+// Tickets returns the user's live tickets, newest first, optionally only
+// those with the given status.
+func Tickets(userID int, status string, limit int) ([]models.KenoTicket, error) {
+	query := `
+		SELECT t.id, t.draw_id, d.draw_at, t.numbers, t.bet, t.currency, t.status, t.hits, t.payout, t.jackpot_won, d.numbers
+		FROM keno_tickets t
+		JOIN keno_draws d ON d.id = t.draw_id
+		WHERE t.user_id = ?`
+	args := []any{userID}
+	if status != "" {
+		query += " AND t.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY t.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []models.KenoTicket{}
+	for rows.Next() {
+		var t models.KenoTicket
+		var picks, drawn []byte
+		var hits sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.DrawID, &t.DrawAt, &picks, &t.Bet, &t.Currency, &t.Status, &hits, &t.Payout, &t.JackpotWon, &drawn); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(picks, &t.SelectedNumbers); err != nil {
+			return nil, err
+		}
+		if hits.Valid {
+			h := int(hits.Int64)
+			t.Hits = &h
+		}
+		// The draw's numbers are only shown on settled tickets.
+		if t.Status == TicketSettled && drawn != nil {
+			if err := json.Unmarshal(drawn, &t.DrawnNumbers); err != nil {
+				return nil, err
+			}
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, rows.Err()
+}
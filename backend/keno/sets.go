@@ -0,0 +1,186 @@
+package keno
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxSets is the most number sets a player can save.
+const MaxSets = 20
+
+var (
+	ErrSetNotFound  = errors.New("keno: number set not found")
+	ErrSetName      = errors.New("keno: a number set needs a name of at most 64 characters")
+	ErrSetNameTaken = errors.New("keno: a number set with that name already exists")
+	ErrTooManySets  = errors.New("keno: too many number sets")
+)
+
+const setColumns = "id, name, numbers, created_at, updated_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSet(row rowScanner) (models.KenoSet, error) {
+	var s models.KenoSet
+	var numbers []byte
+	if err := row.Scan(&s.ID, &s.Name, &numbers, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(numbers, &s.Numbers)
+}
+
+// Sets returns the user's saved number sets, oldest first.
+func Sets(userID int) ([]models.KenoSet, error) {
+	rows, err := database.DB.Query("SELECT "+setColumns+" FROM keno_sets WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sets := []models.KenoSet{}
+	for rows.Next() {
+		s, err := scanSet(rows)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, s)
+	}
+	return sets, rows.Err()
+}
+
+// Set returns one of the user's number sets; other users' sets are reported
+// as ErrSetNotFound.
+func Set(userID int, id int64) (models.KenoSet, error) {
+	s, err := scanSet(database.DB.QueryRow("SELECT "+setColumns+" FROM keno_sets WHERE id = ? AND user_id = ?", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrSetNotFound
+	}
+	return s, err
+}
+
+// CreateSet saves a number set for the user.
+func CreateSet(userID int, name string, numbers []int) (models.KenoSet, error) {
+	name, err := checkSet(name, numbers)
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+	encoded, err := json.Marshal(numbers)
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+	defer tx.Rollback()
+
+	// Saves are serialised on the user's row so concurrent ones cannot go
+	// past the cap.
+	var locked int
+	if err := tx.QueryRow("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&locked); err != nil {
+		return models.KenoSet{}, err
+	}
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM keno_sets WHERE user_id = ?", userID).Scan(&count); err != nil {
+		return models.KenoSet{}, err
+	}
+	if count >= MaxSets {
+		return models.KenoSet{}, ErrTooManySets
+	}
+	if taken, err := nameTakenTx(tx, userID, name, 0); err != nil || taken {
+		if err == nil {
+			err = ErrSetNameTaken
+		}
+		return models.KenoSet{}, err
+	}
+
+	now := time.Now()
+	res, err := tx.Exec(`
+		INSERT INTO keno_sets (user_id, name, numbers, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)`, userID, name, encoded, now, now)
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.KenoSet{}, err
+	}
+	return models.KenoSet{ID: id, Name: name, Numbers: numbers, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+// UpdateSet renames a number set and replaces its numbers.
+func UpdateSet(userID int, id int64, name string, numbers []int) (models.KenoSet, error) {
+	name, err := checkSet(name, numbers)
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+	encoded, err := json.Marshal(numbers)
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return models.KenoSet{}, err
+	}
+	defer tx.Rollback()
+
+	s, err := scanSet(tx.QueryRow("SELECT "+setColumns+" FROM keno_sets WHERE id = ? AND user_id = ? FOR UPDATE", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrSetNotFound
+	}
+	if err != nil {
+		return s, err
+	}
+	if taken, err := nameTakenTx(tx, userID, name, id); err != nil || taken {
+		if err == nil {
+			err = ErrSetNameTaken
+		}
+		return s, err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE keno_sets SET name = ?, numbers = ?, updated_at = ? WHERE id = ?", name, encoded, now, id); err != nil {
+		return s, err
+	}
+	if err := tx.Commit(); err != nil {
+		return s, err
+	}
+	s.Name, s.Numbers, s.UpdatedAt = name, numbers, now
+	return s, nil
+}
+
+// DeleteSet removes one of the user's number sets.
+func DeleteSet(userID int, id int64) error {
+	res, err := database.DB.Exec("DELETE FROM keno_sets WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSetNotFound
+	}
+	return nil
+}
+
+func checkSet(name string, numbers []int) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 64 {
+		return "", ErrSetName
+	}
+	return name, ValidatePicks(numbers)
+}
+
+func nameTakenTx(tx *sql.Tx, userID int, name string, except int64) (bool, error) {
+	var n int
+	err := tx.QueryRow("SELECT COUNT(*) FROM keno_sets WHERE user_id = ? AND name = ? AND id <> ?", userID, name, except).Scan(&n)
+	return n > 0, err
+}
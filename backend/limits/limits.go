@@ -0,0 +1,336 @@
+// Package limits enforces the responsible gaming limits players set on
+// themselves: loss and wager limits per calendar day, week or month and a
+// maximum session length.
+//
+// Lowering a limit (or setting a new one) applies at once. Raising or
+// removing one only takes effect after CoolingOff, so a player cannot undo a
+// limit in the heat of the moment.
+package limits
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"casino-hub/backend/session"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	Loss          = "loss"
+	Wager         = "wager"
+	SessionLength = "session"
+
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+
+	defaultCoolingOff = 24 * time.Hour
+)
+
+var (
+	ErrLimitReached = errors.New("limits: limit reached")
+	ErrInvalidLimit = errors.New("limits: invalid limit")
+)
+
+// LimitError reports which limit stopped a bet. It matches ErrLimitReached
+// with errors.Is.
+type LimitError struct {
+	Type     string       `json:"type"`
+	Period   string       `json:"period,omitempty"`
+	Currency string       `json:"currency,omitempty"`
+	Limit    money.Amount `json:"limit,omitempty"`
+	Used     money.Amount `json:"used,omitempty"`
+	Minutes  int          `json:"minutes,omitempty"`
+}
+
+func (e *LimitError) Error() string {
+	if e.Type == SessionLength {
+		return fmt.Sprintf("limits: session limit of %d minutes reached", e.Minutes)
+	}
+	return fmt.Sprintf("limits: %s %s limit of %s %s reached", e.Period, e.Type, e.Limit, e.Currency)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitReached
+}
+
+// Code is the machine-readable error code sent to clients.
+func (e *LimitError) Code() string {
+	return e.Type + "_limit_reached"
+}
+
+// CoolingOff is how long a raised or removed limit waits before it applies,
+// set with LIMIT_COOLING_OFF (a Go duration such as "24h").
+func CoolingOff() time.Duration {
+	if v := os.Getenv("LIMIT_COOLING_OFF"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		log.Println("Invalid LIMIT_COOLING_OFF, using default:", v)
+	}
+	return defaultCoolingOff
+}
+
+// PeriodStart returns the start of the calendar day, week (from Monday) or
+// month containing now.
+func PeriodStart(period string, now time.Time) time.Time {
+	y, m, d := now.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch period {
+	case Weekly:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case Monthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	}
+	return day
+}
+
+// stricter reports whether changing a limit from current to value tightens
+// it, so the change can apply at once. Zero is no limit.
+func stricter(value, current int64) bool {
+	return value > 0 && (current == 0 || value <= current)
+}
+
+// limit is one row of player_limits. Value is in minor units for loss and
+// wager limits and in minutes for the session limit; zero means no limit.
+type limit struct {
+	Currency  string
+	Type      string
+	Period    string
+	Value     int64
+	Pending   sql.NullInt64
+	PendingAt sql.NullTime
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// applyDue promotes pending changes whose cooling-off period has passed.
+func applyDue(db execer, userID int, now time.Time) error {
+	_, err := db.Exec(`
+		UPDATE player_limits
+		SET value = pending_value, pending_value = NULL, pending_effective_at = NULL, updated_at = ?
+		WHERE user_id = ? AND pending_effective_at <= ?`, now, userID, now)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM player_limits WHERE user_id = ? AND value = 0 AND pending_value IS NULL", userID)
+	return err
+}
+
+func load(db querier, userID int, where string, args ...any) ([]limit, error) {
+	rows, err := db.Query(`
+		SELECT currency, limit_type, period, value, pending_value, pending_effective_at
+		FROM player_limits
+		WHERE user_id = ?`+where+`
+		ORDER BY limit_type, currency, FIELD(period, 'daily', 'weekly', 'monthly')`, append([]any{userID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []limit
+	for rows.Next() {
+		var l limit
+		if err := rows.Scan(&l.Currency, &l.Type, &l.Period, &l.Value, &l.Pending, &l.PendingAt); err != nil {
+			return nil, err
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
+
+// used returns how much of a loss or wager allowance the player has used in
+// the period starting at since. Losses are net of winnings and refunds.
+func used(db querier, userID int, currency, limitType string, since time.Time) (money.Amount, error) {
+	var total money.Amount
+	var err error
+	if limitType == Wager {
+		err = db.QueryRow(`
+			SELECT COALESCE(SUM(amount), 0) FROM wallet_transactions
+			WHERE user_id = ? AND account = ? AND currency = ? AND reason = ? AND created_at >= ?`,
+			userID, wallet.PlayerAccount, currency, wallet.ReasonBet, since).Scan(&total)
+	} else {
+		err = db.QueryRow(`
+			SELECT COALESCE(SUM(IF(reason = ?, amount, -amount)), 0) FROM wallet_transactions
+			WHERE user_id = ? AND account = ? AND currency = ? AND reason IN (?, ?, ?) AND game IS NOT NULL AND created_at >= ?`,
+			wallet.ReasonBet, userID, wallet.PlayerAccount, currency, wallet.ReasonBet, wallet.ReasonWin, wallet.ReasonRefund, since).Scan(&total)
+	}
+	return max(total, 0), err
+}
+
+// CheckBet rejects a stake that would take the player past any of their
+// limits. It is meant to be used as wallet.Bet.Check, so it runs with the
+// player's balance locked.
+func CheckBet(tx *sql.Tx, bet wallet.Bet) error {
+	now := time.Now()
+	if err := applyDue(tx, bet.UserID, now); err != nil {
+		return err
+	}
+	limits, err := load(tx, bet.UserID, " AND value > 0 AND (currency = ? OR limit_type = ?)", bet.Currency, SessionLength)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range limits {
+		if l.Type == SessionLength {
+			s, ok, err := session.CurrentTx(tx, bet.UserID, now)
+			if err != nil {
+				return err
+			}
+			if ok && s.Length(now) >= time.Duration(l.Value)*time.Minute {
+				return &LimitError{Type: SessionLength, Minutes: int(l.Value)}
+			}
+			continue
+		}
+
+		spent, err := used(tx, bet.UserID, l.Currency, l.Type, PeriodStart(l.Period, now))
+		if err != nil {
+			return err
+		}
+		if spent+bet.Stake > money.Amount(l.Value) {
+			return &LimitError{Type: l.Type, Period: l.Period, Currency: l.Currency, Limit: money.Amount(l.Value), Used: spent}
+		}
+	}
+	return nil
+}
+
+// List returns the player's limits with how much of each is used up.
+func List(userID int) (models.Limits, error) {
+	now := time.Now()
+	if err := applyDue(database.DB, userID, now); err != nil {
+		return models.Limits{}, err
+	}
+	limits, err := load(database.DB, userID, "")
+	if err != nil {
+		return models.Limits{}, err
+	}
+
+	res := models.Limits{Limits: []models.AmountLimit{}, CoolingOff: CoolingOff().String()}
+	for _, l := range limits {
+		var pending *models.PendingLimit
+		if l.Pending.Valid {
+			pending = &models.PendingLimit{EffectiveAt: l.PendingAt.Time}
+			if l.Type == SessionLength {
+				pending.Minutes = int(l.Pending.Int64)
+			} else {
+				pending.Amount = money.Amount(l.Pending.Int64)
+			}
+		}
+
+		if l.Type == SessionLength {
+			sl := &models.SessionLimit{Minutes: int(l.Value), Pending: pending}
+			s, ok, err := session.Current(userID, now)
+			if err != nil {
+				return models.Limits{}, err
+			}
+			if ok {
+				sl.UsedMinutes = int(s.Length(now) / time.Minute)
+				sl.SessionStartedAt = &s.StartedAt
+			}
+			res.Session = sl
+			continue
+		}
+
+		start := PeriodStart(l.Period, now)
+		al := models.AmountLimit{
+			Type:        l.Type,
+			Period:      l.Period,
+			Currency:    l.Currency,
+			Amount:      money.Amount(l.Value),
+			PeriodStart: start,
+			Pending:     pending,
+		}
+		if l.Value > 0 {
+			if al.Used, err = used(database.DB, userID, l.Currency, l.Type, start); err != nil {
+				return models.Limits{}, err
+			}
+			al.Remaining = max(al.Amount-al.Used, 0)
+		}
+		res.Limits = append(res.Limits, al)
+	}
+	return res, nil
+}
+
+// Update applies a set of limit changes in one go. Stricter values apply
+// immediately; looser ones are queued behind the cooling-off period.
+func Update(userID int, u models.LimitsUpdate) error {
+	type change struct {
+		currency, limitType, period string
+		value                       int64
+	}
+	var changes []change
+	for _, l := range u.Limits {
+		if l.Type != Loss && l.Type != Wager {
+			return fmt.Errorf("%w: type must be loss or wager", ErrInvalidLimit)
+		}
+		if l.Period != Daily && l.Period != Weekly && l.Period != Monthly {
+			return fmt.Errorf("%w: period must be daily, weekly or monthly", ErrInvalidLimit)
+		}
+		c, err := wallet.LookupCurrency(l.Currency)
+		if err != nil {
+			return fmt.Errorf("%w: unknown currency", ErrInvalidLimit)
+		}
+		if l.Amount < 0 {
+			return fmt.Errorf("%w: amount cannot be negative", ErrInvalidLimit)
+		}
+		changes = append(changes, change{c.Code, l.Type, l.Period, int64(l.Amount)})
+	}
+	if u.SessionMinutes != nil {
+		if *u.SessionMinutes < 0 {
+			return fmt.Errorf("%w: sessionMinutes cannot be negative", ErrInvalidLimit)
+		}
+		changes = append(changes, change{"", SessionLength, "", int64(*u.SessionMinutes)})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := applyDue(tx, userID, now); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		var current int64
+		err := tx.QueryRow(`
+			SELECT value FROM player_limits
+			WHERE user_id = ? AND currency = ? AND limit_type = ? AND period = ?
+			FOR UPDATE`, userID, c.currency, c.limitType, c.period).Scan(&current)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		switch {
+		case stricter(c.value, current):
+			_, err = tx.Exec(`
+				INSERT INTO player_limits (user_id, currency, limit_type, period, value, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE value = VALUES(value), pending_value = NULL, pending_effective_at = NULL, updated_at = VALUES(updated_at)`,
+				userID, c.currency, c.limitType, c.period, c.value, now)
+		case current > 0:
+			_, err = tx.Exec(`
+				UPDATE player_limits SET pending_value = ?, pending_effective_at = ?, updated_at = ?
+				WHERE user_id = ? AND currency = ? AND limit_type = ? AND period = ?`,
+				c.value, now.Add(CoolingOff()), now, userID, c.currency, c.limitType, c.period)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,137 @@
+package limits
+
+import (
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// 2024-05-15 was a Wednesday.
+	now := time.Date(2024, 5, 15, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		now    time.Time
+		want   time.Time
+	}{
+		{Daily, now, time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{Weekly, now, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{Monthly, now, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		// Weeks start on Monday, so a Sunday belongs to the week before.
+		{Weekly, time.Date(2024, 5, 19, 23, 59, 0, 0, time.UTC), time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		// A week can start in the month before.
+		{Weekly, time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC), time.Date(2024, 5, 27, 0, 0, 0, 0, time.UTC)},
+		{"", now, time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := PeriodStart(tt.period, tt.now); !got.Equal(tt.want) {
+			t.Errorf("PeriodStart(%q, %s) = %s, want %s", tt.period, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestPeriodStartKeepsTheLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	// Still the 15th where the player is, though the 14th in UTC.
+	now := time.Date(2024, 5, 15, 7, 0, 0, 0, loc)
+	want := time.Date(2024, 5, 15, 0, 0, 0, 0, loc)
+	if got := PeriodStart(Daily, now); !got.Equal(want) {
+		t.Errorf("PeriodStart(%q, %s) = %s, want %s", Daily, now, got, want)
+	}
+}
+
+func TestStricter(t *testing.T) {
+	tests := []struct {
+		name           string
+		value, current int64
+		want           bool
+	}{
+		{"new limit", 1000, 0, true},
+		{"lowered", 500, 1000, true},
+		{"set again", 1000, 1000, true},
+		{"raised", 2000, 1000, false},
+		{"removed", 0, 1000, false},
+		{"removing none", 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := stricter(tt.value, tt.current); got != tt.want {
+			t.Errorf("%s: stricter(%d, %d) = %v, want %v", tt.name, tt.value, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestCoolingOff(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", defaultCoolingOff},
+		{"72h", 72 * time.Hour},
+		{"0s", 0},
+		{"-1h", defaultCoolingOff},
+		{"a day", defaultCoolingOff},
+	}
+	for _, tt := range tests {
+		t.Setenv("LIMIT_COOLING_OFF", tt.env)
+		if got := CoolingOff(); got != tt.want {
+			t.Errorf("CoolingOff with %q = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestLimitError(t *testing.T) {
+	tests := []struct {
+		err  *LimitError
+		msg  string
+		code string
+	}{
+		{&LimitError{Type: Loss, Period: Daily, Currency: "GC", Limit: money.Coins(100), Used: money.Coins(90)},
+			"limits: daily loss limit of 100.00 GC reached", "loss_limit_reached"},
+		{&LimitError{Type: Wager, Period: Monthly, Currency: "SC", Limit: money.Coins(50)},
+			"limits: monthly wager limit of 50.00 SC reached", "wager_limit_reached"},
+		{&LimitError{Type: SessionLength, Minutes: 90},
+			"limits: session limit of 90 minutes reached", "session_limit_reached"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.msg {
+			t.Errorf("Error() = %q, want %q", got, tt.msg)
+		}
+		if got := tt.err.Code(); got != tt.code {
+			t.Errorf("Code() = %q, want %q", got, tt.code)
+		}
+		if !errors.Is(tt.err, ErrLimitReached) {
+			t.Errorf("%v does not match %v", tt.err, ErrLimitReached)
+		}
+	}
+}
+
+func TestUpdateRejectsInvalidLimits(t *testing.T) {
+	negative := -5
+	tests := []struct {
+		name   string
+		update models.LimitsUpdate
+	}{
+		{"unknown type", models.LimitsUpdate{Limits: []models.LimitUpdate{{Type: "deposit", Period: Daily, Amount: 100}}}},
+		{"session as an amount", models.LimitsUpdate{Limits: []models.LimitUpdate{{Type: SessionLength, Period: Daily, Amount: 100}}}},
+		{"unknown period", models.LimitsUpdate{Limits: []models.LimitUpdate{{Type: Loss, Period: "yearly", Amount: 100}}}},
+		{"unknown currency", models.LimitsUpdate{Limits: []models.LimitUpdate{{Type: Loss, Period: Daily, Currency: "XX", Amount: 100}}}},
+		{"negative amount", models.LimitsUpdate{Limits: []models.LimitUpdate{{Type: Wager, Period: Weekly, Amount: -1}}}},
+		{"negative session", models.LimitsUpdate{SessionMinutes: &negative}},
+		// One bad change rejects the whole update.
+		{"bad change after a good one", models.LimitsUpdate{Limits: []models.LimitUpdate{
+			{Type: Loss, Period: Daily, Amount: 100},
+			{Type: Loss, Period: "hourly", Amount: 100},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Invalid updates are turned away before the database is used.
+			if err := Update(1, tt.update); !errors.Is(err, ErrInvalidLimit) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidLimit)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"casino-hub/backend/database"
 	"casino-hub/backend/routes"
+	"casino-hub/backend/tasks"
 
 	"github.com/joho/godotenv"
 
@@ -33,6 +34,14 @@ func main() {
 	database.InitDB()
 	defer database.DB.Close()
 
+	if err := database.Migrate(); err != nil {
+		log.Fatal("❌ Failed to migrate database:", err)
+	}
+
+	go tasks.PurgeExpiredIdempotencyKeys()
+	go tasks.RunKenoDraws()
+	go tasks.ExpireBlackjackRounds()
+
 	// Router
 	r := mux.NewRouter()
 	routes.RegisterRoutes(r)
@@ -1,5 +1,7 @@
 package models
 
+import "casino-hub/backend/money"
+
 type BetType string
 
 func (b BetType) String() any {
@@ -10,6 +12,21 @@ const (
 	Player BetType = "PLAYER"
 	Banker BetType = "BANKER"
 	Tie    BetType = "TIE"
+
+	// Side bets
+	PlayerPair   BetType = "PLAYER_PAIR"
+	BankerPair   BetType = "BANKER_PAIR"
+	EitherPair   BetType = "EITHER_PAIR"
+	PerfectPair  BetType = "PERFECT_PAIR"
+	PlayerDragon BetType = "PLAYER_DRAGON" // Dragon Bonus on the player
+	BankerDragon BetType = "BANKER_DRAGON" // Dragon Bonus on the banker
+)
+
+// Wager outcomes
+const (
+	WagerWin  = "win"
+	WagerLose = "lose"
+	WagerPush = "push"
 )
 
 type BaccaratCard struct {
@@ -19,20 +36,70 @@ type BaccaratCard struct {
 	FaceValue int    `json:"faceValue"`
 }
 
+type Wager struct {
+	Type   BetType      `json:"type"`
+	Amount money.Amount `json:"amount"`
+}
+
+// Bet is a baccarat bet slip. Wagers holds every wager of the round; Type
+// and Amount are a single wager, for clients that only place one.
 type Bet struct {
-	Type   BetType `json:"type"`
-	Amount int     `json:"amount"`
+	Type     BetType      `json:"type"`
+	Amount   money.Amount `json:"amount"`
+	Wagers   []Wager      `json:"wagers"`
+	Currency string       `json:"currency"`
+	Table    string       `json:"table"` // standard or no-commission
+	// TableNumber picks one of the variant's tables. Players are seated by
+	// account when it is left out.
+	TableNumber int `json:"tableNumber,omitempty"`
+}
+
+type WagerResult struct {
+	Type    BetType      `json:"type"`
+	Amount  money.Amount `json:"amount"`
+	Outcome string       `json:"outcome"` // win, lose or push
+	// Payout is what the wager returns, stake included.
+	Payout money.Amount `json:"payout"`
 }
 
 type GameResult struct {
-	PlayerCards []BaccaratCard `json:"playerCards"`
-	BankerCards []BaccaratCard `json:"bankerCards"`
-	PlayerTotal int            `json:"playerTotal"`
-	BankerTotal int            `json:"bankerTotal"`
-	Winner      BetType        `json:"winner"`
-	WinAmount   int            `json:"winAmount"`
-	NewBalance  int            `json:"newBalance"`
-	Message     string         `json:"message"`
+	PlayerCards     []BaccaratCard `json:"playerCards"`
+	BankerCards     []BaccaratCard `json:"bankerCards"`
+	PlayerTotal     int            `json:"playerTotal"`
+	BankerTotal     int            `json:"bankerTotal"`
+	Winner          BetType        `json:"winner"`
+	Table           string         `json:"table"`
+	TableNumber     int            `json:"tableNumber"`
+	Wagers          []WagerResult  `json:"wagers"`
+	WinAmount       money.Amount   `json:"winAmount"`
+	NewBalance      money.Amount   `json:"newBalance"`
+	Currency        string         `json:"currency"`
+	Message         string         `json:"message"`
+	RealityCheckDue bool           `json:"realityCheckDue"`
 }
 
 var suits = []string{"♠", "♥", "♦", "♣"}
+
+// RoadCell is one mark on a baccarat scoreboard. Result is P, B or T on the
+// bead plate and big road, and red or blue on the derived roads.
+type RoadCell struct {
+	Col        int    `json:"col"`
+	Row        int    `json:"row"`
+	Result     string `json:"result"`
+	Ties       int    `json:"ties,omitempty"`
+	PlayerPair bool   `json:"playerPair,omitempty"`
+	BankerPair bool   `json:"bankerPair,omitempty"`
+}
+
+// BaccaratRoads is the scoreboard of one shoe.
+type BaccaratRoads struct {
+	Table        string     `json:"table"`
+	TableNumber  int        `json:"tableNumber"`
+	Shoe         int        `json:"shoe"`
+	Rounds       int        `json:"rounds"`
+	BeadPlate    []RoadCell `json:"beadPlate"`
+	BigRoad      []RoadCell `json:"bigRoad"`
+	BigEyeBoy    []RoadCell `json:"bigEyeBoy"`
+	SmallRoad    []RoadCell `json:"smallRoad"`
+	CockroachPig []RoadCell `json:"cockroachPig"`
+}
@@ -1,18 +1,67 @@
 package models
 
+import "casino-hub/backend/money"
+
 type Card struct {
 	Suit     string `json:"suit"`
 	Value    string `json:"value"`
 	NumValue int    `json:"numValue"`
 }
 
+// BlackjackRules are the rules of a blackjack table. Every round keeps a
+// copy of the rules it was dealt under.
+type BlackjackRules struct {
+	Table            string `json:"table"`
+	Name             string `json:"name"`
+	Decks            int    `json:"decks"`
+	HitSoft17        bool   `json:"hitSoft17"`
+	BlackjackPays    string `json:"blackjackPays"` // 3:2 or 6:5
+	DoubleAfterSplit bool   `json:"doubleAfterSplit"`
+	// MaxSplits is how many times a round can be split, so a round has at
+	// most MaxSplits+1 hands.
+	MaxSplits int          `json:"maxSplits"`
+	MinBet    money.Amount `json:"minBet"`
+	// MaxBet of zero means no table maximum.
+	MaxBet money.Amount `json:"maxBet"`
+}
+
+// BlackjackHand is one of the player's hands. Splitting a pair turns one
+// hand into two, each with its own stake and outcome.
+type BlackjackHand struct {
+	Cards  []Card       `json:"cards"`
+	Bet    money.Amount `json:"bet"`
+	Score  int          `json:"score"`
+	Status string       `json:"status"` // playing, stood, bust or surrendered
+	// Doubled hands took exactly one more card for a second stake.
+	Doubled bool `json:"doubled,omitempty"`
+	// SplitAces hands get one card each and cannot be played further.
+	SplitAces bool         `json:"splitAces,omitempty"`
+	Result    string       `json:"result,omitempty"` // blackjack, win, push, lose or surrender
+	WinAmount money.Amount `json:"winAmount"`
+}
+
+// GameState is a blackjack round as the player sees it. The deck stays on
+// the server, and so does the dealer's hole card until the round is over.
 type GameState struct {
-	PlayerCards []Card `json:"playerCards"`
-	DealerCards []Card `json:"dealerCards"`
-	Deck        []Card `json:"deck"`
-	Coins       int    `json:"coins"`
-	Bet         int    `json:"bet"`
-	Message     string `json:"message"`
-	GameOver    bool   `json:"gameOver"`
-	WinAmount   int    `json:"winAmount"`
+	RoundID string         `json:"roundId"`
+	Rules   BlackjackRules `json:"rules"`
+	// PlayerCards are the cards of the hand in play.
+	PlayerCards []Card          `json:"playerCards"`
+	Hands       []BlackjackHand `json:"hands"`
+	ActiveHand  int             `json:"activeHand"`
+	DealerCards []Card          `json:"dealerCards"`
+	Coins       money.Amount    `json:"coins"`
+	// Bet is the total staked on the player's hands, Insurance the side bet.
+	Bet              money.Amount `json:"bet"`
+	Insurance        money.Amount `json:"insurance"`
+	InsuranceOffered bool         `json:"insuranceOffered"`
+	Currency         string       `json:"currency"`
+	Message          string       `json:"message"`
+	GameOver         bool         `json:"gameOver"`
+	// WinAmount is everything paid back at the end of the round, stakes
+	// included.
+	WinAmount money.Amount `json:"winAmount"`
+	// Actions lists the moves the player can make next.
+	Actions         []string `json:"actions"`
+	RealityCheckDue bool     `json:"realityCheckDue"`
 }
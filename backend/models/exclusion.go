@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type SelfExclusion struct {
+	ID       int64      `json:"id"`
+	Period   string     `json:"period"` // 24h, 7d, 6m or permanent
+	StartsAt time.Time  `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt,omitempty"` // nil for permanent exclusions
+	// CreatedAt is when the player asked for the exclusion.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SelfExclusionStatus struct {
+	Active  *SelfExclusion  `json:"active"`
+	History []SelfExclusion `json:"history"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "casino-hub/backend/money"
+
 type HiLoCard struct {
 	Value int    `json:"value"` // 1-13
 	Suit  string `json:"suit"`  // "♠", "♥", "♦", "♣"
@@ -7,19 +9,42 @@ type HiLoCard struct {
 }
 
 type HiLoRequest struct {
-	Guess string `json:"guess"` // "higher" or "lower"
-	Bet   int    `json:"bet"`
+	Guess    string       `json:"guess"` // "higher", "lower" or "tie"
+	Bet      money.Amount `json:"bet"`   // only used to start a round
+	Currency string       `json:"currency"`
+}
+
+// HiLoOdds are what the pot is multiplied by for a correct guess of each
+// kind, given the cards left in the deck. Impossible guesses are 0.
+type HiLoOdds struct {
+	Higher float64 `json:"higher"`
+	Lower  float64 `json:"lower"`
+	Tie    float64 `json:"tie"`
 }
 
+// HiLoResponse is the player's round after a request. CardFrom, CardTo,
+// Guess and Won describe the guess just played, when there was one.
 type HiLoResponse struct {
-	CardFrom     HiLoCard   `json:"cardFrom"`
-	CardTo       HiLoCard   `json:"cardTo"`
-	Guess        string `json:"guess"`
-	Won          bool   `json:"won"`
-	Payout       int    `json:"payout"`
-	Balance      int    `json:"balance"`
-	Streak       int    `json:"streak"`
-	Message      string `json:"message"`
+	RoundID     string       `json:"roundId"`
+	Status      string       `json:"status"` // "active", "cashed_out" or "lost"
+	Bet         money.Amount `json:"bet"`
+	Pot         money.Amount `json:"pot"`
+	CurrentCard HiLoCard     `json:"currentCard"`
+	CardsLeft   int          `json:"cardsLeft"`
+	Odds        *HiLoOdds    `json:"odds,omitempty"`
+
+	CardFrom *HiLoCard `json:"cardFrom,omitempty"`
+	CardTo   *HiLoCard `json:"cardTo,omitempty"`
+	Guess    string    `json:"guess,omitempty"`
+	Won      bool      `json:"won"`
+	// Payout is what the pot gained on a correct guess, nothing on a wrong
+	// one, and the pot banked on cash-out.
+	Payout          money.Amount `json:"payout"`
+	Balance         money.Amount `json:"balance"`
+	Currency        string       `json:"currency"`
+	Streak          int          `json:"streak"`
+	Message         string       `json:"message"`
+	RealityCheckDue bool         `json:"realityCheckDue"`
 }
 
 var HiLoSuits = []struct {
@@ -31,6 +56,3 @@ var HiLoSuits = []struct {
 	{"♦", "red"},
 	{"♣", "black"},
 }
-
-var Balance = 50000
-var Streak = 0
\ No newline at end of file
@@ -1,17 +1,33 @@
-package models 
+package models
 
+import (
+	"casino-hub/backend/money"
+	"time"
+)
+
+// KenoRequest plays a ticket. Its numbers are SelectedNumbers, QuickPick
+// numbers picked by the server, or the saved set SetID; exactly one of them
+// is given.
 type KenoRequest struct {
-	SelectedNumbers []int `json:"selectedNumbers"` // user picks 1-10 numbers
-	Bet             int   `json:"bet"`
+	SelectedNumbers []int        `json:"selectedNumbers"` // user picks 1-10 numbers
+	QuickPick       int          `json:"quickPick,omitempty"`
+	SetID           int64        `json:"setId,omitempty"`
+	Bet             money.Amount `json:"bet"`
+	Currency        string       `json:"currency"`
 }
 
 type KenoResponse struct {
-	DrawnNumbers []int `json:"drawnNumbers"`
-	Hits         int   `json:"hits"`
-	Payout       int   `json:"payout"`
-	JackpotWon   bool  `json:"jackpotWon"`
-	NewBalance   int   `json:"newBalance"`
-	Message      string `json:"message"`
+	SelectedNumbers []int               `json:"selectedNumbers"`
+	DrawnNumbers    []int               `json:"drawnNumbers"`
+	Hits            int                 `json:"hits"`
+	Payout          money.Amount        `json:"payout"`
+	JackpotWon      bool                `json:"jackpotWon"`
+	Jackpot         money.Amount        `json:"jackpot"` // the jackpot after this round
+	NewBalance      money.Amount        `json:"newBalance"`
+	Currency        string              `json:"currency"`
+	Message         string              `json:"message"`
+	MysteryJackpots []MysteryJackpotWin `json:"mysteryJackpots,omitempty"`
+	RealityCheckDue bool                `json:"realityCheckDue"`
 }
 
 var PayoutTable = map[int][]int{
@@ -27,4 +43,62 @@ var PayoutTable = map[int][]int{
 	10: {0, 0, 0, 0, 2, 4, 17, 70, 400, 1800, 100000},
 }
 
-var JackpotBase = 50000
\ No newline at end of file
+// KenoDraw is a scheduled live draw. Numbers are set once it has been drawn.
+type KenoDraw struct {
+	ID      int64      `json:"id"`
+	DrawAt  time.Time  `json:"drawAt"`
+	Status  string     `json:"status"` // "open" or "drawn"
+	Numbers []int      `json:"numbers,omitempty"`
+	DrawnAt *time.Time `json:"drawnAt,omitempty"`
+}
+
+// KenoTicketRequest buys the same ticket for Draws consecutive live draws,
+// starting with the next one.
+type KenoTicketRequest struct {
+	// The numbers are chosen as in KenoRequest.
+	SelectedNumbers []int        `json:"selectedNumbers"`
+	QuickPick       int          `json:"quickPick,omitempty"`
+	SetID           int64        `json:"setId,omitempty"`
+	Bet             money.Amount `json:"bet"` // per draw
+	Currency        string       `json:"currency"`
+	Draws           int          `json:"draws"` // defaults to 1
+}
+
+// KenoTicket is a ticket for one live draw. Hits, Payout and DrawnNumbers
+// are filled in once the draw has been settled.
+type KenoTicket struct {
+	ID              int64        `json:"id"`
+	DrawID          int64        `json:"drawId"`
+	DrawAt          time.Time    `json:"drawAt"`
+	SelectedNumbers []int        `json:"selectedNumbers"`
+	Bet             money.Amount `json:"bet"`
+	Currency        string       `json:"currency"`
+	Status          string       `json:"status"` // "pending", "settled" or "void"
+	Hits            *int         `json:"hits,omitempty"`
+	Payout          money.Amount `json:"payout"`
+	JackpotWon      bool         `json:"jackpotWon"`
+	DrawnNumbers    []int        `json:"drawnNumbers,omitempty"`
+}
+
+type KenoTicketResponse struct {
+	Tickets         []KenoTicket        `json:"tickets"`
+	TotalStake      money.Amount        `json:"totalStake"`
+	NewBalance      money.Amount        `json:"newBalance"`
+	Currency        string              `json:"currency"`
+	MysteryJackpots []MysteryJackpotWin `json:"mysteryJackpots,omitempty"`
+	RealityCheckDue bool                `json:"realityCheckDue"`
+}
+
+// KenoSet is a saved set of numbers that can be played by its ID.
+type KenoSet struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Numbers   []int     `json:"numbers"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type KenoSetRequest struct {
+	Name    string `json:"name"`
+	Numbers []int  `json:"numbers"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"casino-hub/backend/money"
+	"time"
+)
+
+// LimitUpdate sets a loss or wager limit for one period and currency. An
+// amount of zero removes the limit.
+type LimitUpdate struct {
+	Type     string       `json:"type"`   // loss or wager
+	Period   string       `json:"period"` // daily, weekly or monthly
+	Currency string       `json:"currency"`
+	Amount   money.Amount `json:"amount"`
+}
+
+type LimitsUpdate struct {
+	Limits []LimitUpdate `json:"limits"`
+	// SessionMinutes caps the length of a play session; nil leaves the
+	// current setting alone and zero removes it.
+	SessionMinutes *int `json:"sessionMinutes"`
+}
+
+// PendingLimit is a raised or removed limit waiting out its cooling-off
+// period. A zero value means the limit is being removed.
+type PendingLimit struct {
+	Amount      money.Amount `json:"amount,omitempty"`
+	Minutes     int          `json:"minutes,omitempty"`
+	EffectiveAt time.Time    `json:"effectiveAt"`
+}
+
+type AmountLimit struct {
+	Type        string        `json:"type"`
+	Period      string        `json:"period"`
+	Currency    string        `json:"currency"`
+	Amount      money.Amount  `json:"amount"`
+	Used        money.Amount  `json:"used"`
+	Remaining   money.Amount  `json:"remaining"`
+	PeriodStart time.Time     `json:"periodStart"`
+	Pending     *PendingLimit `json:"pending,omitempty"`
+}
+
+type SessionLimit struct {
+	Minutes          int           `json:"minutes"`
+	UsedMinutes      int           `json:"usedMinutes"`
+	SessionStartedAt *time.Time    `json:"sessionStartedAt,omitempty"`
+	Pending          *PendingLimit `json:"pending,omitempty"`
+}
+
+type Limits struct {
+	Limits     []AmountLimit `json:"limits"`
+	Session    *SessionLimit `json:"session,omitempty"`
+	CoolingOff string        `json:"coolingOff"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"casino-hub/backend/money"
+	"time"
+)
+
+// MysteryJackpotTier is one tier of the mystery jackpot in a currency. The
+// tier drops at a hidden amount no later than MustDropBy.
+type MysteryJackpotTier struct {
+	Tier       string       `json:"tier"`
+	Amount     money.Amount `json:"amount"`
+	MustDropBy money.Amount `json:"mustDropBy"`
+}
+
+// MysteryJackpotWin is a mystery jackpot tier that dropped. Winner is only
+// filled in on the public list of winners.
+type MysteryJackpotWin struct {
+	Tier     string       `json:"tier"`
+	Currency string       `json:"currency"`
+	Game     string       `json:"game"`
+	Winner   string       `json:"winner,omitempty"`
+	Amount   money.Amount `json:"amount"`
+	WonAt    time.Time    `json:"wonAt"`
+}
+
+type MysteryJackpots struct {
+	Currency      string               `json:"currency"`
+	Tiers         []MysteryJackpotTier `json:"tiers"`
+	RecentWinners []MysteryJackpotWin  `json:"recentWinners"`
+}
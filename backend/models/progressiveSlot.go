@@ -1,11 +1,13 @@
 package models
 
+import "casino-hub/backend/money"
+
 var SYMBOLS = []struct {
-	ID        int
-	Emoji     string
-	Name      string
+	ID         int
+	Emoji      string
+	Name       string
 	Multiplier int
-	Rarity    float64
+	Rarity     float64
 }{
 	{1, "🍒", "Cherry", 2, 0.3},
 	{2, "🍋", "Lemon", 3, 0.25},
@@ -16,12 +18,18 @@ var SYMBOLS = []struct {
 }
 
 type SpinSlotRequest struct {
-	Bet int `json:"bet"`
+	Bet      money.Amount `json:"bet"`
+	Currency string       `json:"currency"`
 }
 
 type SpinSlotResponse struct {
-	ReelResults []int `json:"reelResults"`
-	WinAmount   int   `json:"winAmount"`
-	NewBalance  int   `json:"newBalance"`
-	WinType     string `json:"winType"`
-}
\ No newline at end of file
+	ReelResults     []int               `json:"reelResults"`
+	WinAmount       money.Amount        `json:"winAmount"`
+	NewBalance      money.Amount        `json:"newBalance"`
+	Currency        string              `json:"currency"`
+	WinType         string              `json:"winType"`
+	JackpotWon      bool                `json:"jackpotWon"`
+	Jackpot         money.Amount        `json:"jackpot"` // the jackpot after this spin
+	MysteryJackpots []MysteryJackpotWin `json:"mysteryJackpots,omitempty"`
+	RealityCheckDue bool                `json:"realityCheckDue"`
+}
@@ -1,31 +1,151 @@
-package models 
+package models
+
+import (
+	"casino-hub/backend/money"
+	"time"
+)
 
 type RouletteBet struct {
-	Kind  string      `json:"kind"`  // number, color, parity, dozen, column
+	Kind  string      `json:"kind"`  // number, split, street, corner, sixline, fivenumber, color, parity, highlow, dozen, column, voisins, tiers, orphelins
 	Value interface{} `json:"value"` // number (0-36) or string/int depending on kind
+	// Numbers are the numbers covered by a split, street, corner or six-line.
+	// On the American wheel 00 is written as 37 (DoubleZero).
+	Numbers []int `json:"numbers,omitempty"`
+	// Stake is the amount on this bet when it is part of a slip.
+	Stake money.Amount `json:"stake,omitempty"`
 }
 
+// RouletteRequest places a slip of bets on one spin. Bet and Stake place a
+// single bet for clients that predate slips and are ignored when Bets is set.
 type RouletteRequest struct {
-	Bet   RouletteBet `json:"bet"`
-	Stake int         `json:"stake"`
+	Bet      RouletteBet   `json:"bet"`
+	Stake    money.Amount  `json:"stake"`
+	Bets     []RouletteBet `json:"bets,omitempty"`
+	Currency string        `json:"currency"`
+	// Variant is the wheel to spin: european (the default), american or
+	// french.
+	Variant string `json:"variant"`
+}
+
+// RouletteBetResult is how a bet on the slip fared. Payout is what the bet
+// returns, stake included.
+type RouletteBetResult struct {
+	Kind    string       `json:"kind"`
+	Value   interface{}  `json:"value,omitempty"`
+	Numbers []int        `json:"numbers"`
+	Stake   money.Amount `json:"stake"`
+	Won     bool         `json:"won"`
+	// Imprisoned is set for an even-money bet held En Prison after a zero;
+	// the prison spin decides whether its stake comes back.
+	Imprisoned bool         `json:"imprisoned,omitempty"`
+	Payout     money.Amount `json:"payout"`
 }
 
 type RouletteResponse struct {
-	WinningNumber int     `json:"winningNumber"`
-	Payout        int     `json:"payout"`
-	NewBalance    float64 `json:"newBalance"`
-	Message       string  `json:"message"`
+	Variant       string `json:"variant"`
+	WinningNumber int    `json:"winningNumber"`
+	// WinningPocket is the winning number as printed on the wheel, "00"
+	// included.
+	WinningPocket string `json:"winningPocket"`
+	WinningColor  string `json:"winningColor"`
+	// PrisonNumber is the spin that settled bets held En Prison.
+	PrisonNumber *int `json:"prisonNumber,omitempty"`
+	// Payout is what the spin won over the total stake, or 0.
+	Payout          money.Amount        `json:"payout"`
+	TotalStake      money.Amount        `json:"totalStake"`
+	Bets            []RouletteBetResult `json:"bets"`
+	NewBalance      money.Amount        `json:"newBalance"`
+	Currency        string              `json:"currency"`
+	Message         string              `json:"message"`
+	MysteryJackpots []MysteryJackpotWin `json:"mysteryJackpots,omitempty"`
+	RealityCheckDue bool                `json:"realityCheckDue"`
 }
 
-// POCKETS - European roulette pockets 0-36 with colors
-var POCKETS = []struct {
+// RouletteSpin is a stored outcome of the wheel.
+type RouletteSpin struct {
+	Variant string    `json:"variant"`
+	Number  int       `json:"number"`
+	Pocket  string    `json:"pocket"`
+	Color   string    `json:"color"`
+	SpunAt  time.Time `json:"spunAt"`
+}
+
+type RouletteNumberCount struct {
+	Number int    `json:"number"`
+	Pocket string `json:"pocket"`
+	Count  int    `json:"count"`
+}
+
+// RouletteTally counts the spins that landed in a dozen or column (1-3).
+// Frequency is the share of the window's spins.
+type RouletteTally struct {
+	Value     int     `json:"value"`
+	Count     int     `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
+
+type RouletteColorRatios struct {
+	Red   float64 `json:"red"`
+	Black float64 `json:"black"`
+	Green float64 `json:"green"`
+}
+
+// RouletteWindowStats are the statistics over the last Size spins. Spins is
+// how many there were, which is fewer than Size early on.
+type RouletteWindowStats struct {
+	Size    int                   `json:"size"`
+	Spins   int                   `json:"spins"`
+	Hot     []RouletteNumberCount `json:"hot"`
+	Cold    []RouletteNumberCount `json:"cold"`
+	Colors  RouletteColorRatios   `json:"colors"`
+	Dozens  []RouletteTally       `json:"dozens"`
+	Columns []RouletteTally       `json:"columns"`
+}
+
+type RouletteHistory struct {
+	Variant string                `json:"variant"`
+	Spins   []RouletteSpin        `json:"spins"`
+	Windows []RouletteWindowStats `json:"windows"`
+}
+
+// RouletteVariant describes a wheel and what it costs the player.
+type RouletteVariant struct {
+	Variant string `json:"variant"`
+	Name    string `json:"name"`
+	Pockets int    `json:"pockets"`
+	// EvenMoneyRule is what happens to even-money bets on zero: lost,
+	// la-partage or en-prison.
+	EvenMoneyRule string `json:"evenMoneyRule"`
+	// HouseEdge is the edge on a straight-up number and EvenMoneyHouseEdge
+	// the edge on red/black, both as a fraction of the stake.
+	HouseEdge          float64 `json:"houseEdge"`
+	EvenMoneyHouseEdge float64 `json:"evenMoneyHouseEdge"`
+}
+
+// DoubleZero is the number that stands for the 00 pocket.
+const DoubleZero = 37
+
+type RoulettePocket struct {
 	N     int
 	Color string
-}{
+}
+
+// POCKETS - European roulette pockets 0-36 with colors
+var POCKETS = []RoulettePocket{
 	{0, "green"}, {32, "red"}, {15, "black"}, {19, "red"}, {4, "black"}, {21, "red"},
 	{2, "black"}, {25, "red"}, {17, "black"}, {34, "red"}, {6, "black"}, {27, "red"},
 	{13, "black"}, {36, "red"}, {11, "black"}, {30, "red"}, {8, "black"}, {23, "red"},
 	{10, "black"}, {5, "red"}, {24, "black"}, {16, "red"}, {33, "black"}, {1, "red"},
 	{20, "black"}, {14, "red"}, {31, "black"}, {9, "red"}, {22, "black"}, {18, "red"},
 	{29, "black"}, {7, "red"}, {28, "black"}, {12, "red"}, {35, "black"}, {3, "red"}, {26, "black"},
-}
\ No newline at end of file
+}
+
+// AMERICAN_POCKETS - American roulette pockets 0, 00 and 1-36 in wheel order
+var AMERICAN_POCKETS = []RoulettePocket{
+	{0, "green"}, {28, "black"}, {9, "red"}, {26, "black"}, {30, "red"}, {11, "black"},
+	{7, "red"}, {20, "black"}, {32, "red"}, {17, "black"}, {5, "red"}, {22, "black"},
+	{34, "red"}, {15, "black"}, {3, "red"}, {24, "black"}, {36, "red"}, {13, "black"}, {1, "red"},
+	{DoubleZero, "green"}, {27, "red"}, {10, "black"}, {25, "red"}, {29, "black"}, {12, "red"},
+	{8, "black"}, {19, "red"}, {31, "black"}, {18, "red"}, {6, "black"}, {21, "red"},
+	{33, "black"}, {16, "red"}, {4, "black"}, {23, "red"}, {35, "black"}, {14, "red"}, {2, "black"},
+}
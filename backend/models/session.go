@@ -0,0 +1,25 @@
+package models
+
+import (
+	"casino-hub/backend/money"
+	"time"
+)
+
+// SessionNet is the player's net result in one currency since the session
+// started; negative when they are down.
+type SessionNet struct {
+	Currency string       `json:"currency"`
+	Net      money.Amount `json:"net"`
+}
+
+// RealityCheck reports how long the player has been playing and how they
+// are doing. Active is false when the player is not in a session.
+type RealityCheck struct {
+	Active           bool         `json:"active"`
+	SessionStartedAt *time.Time   `json:"sessionStartedAt,omitempty"`
+	SessionMinutes   int          `json:"sessionMinutes"`
+	Net              []SessionNet `json:"net"`
+	// Due is set while a reality check is waiting to be acknowledged.
+	Due         bool       `json:"realityCheckDue"`
+	NextCheckAt *time.Time `json:"nextCheckAt,omitempty"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"casino-hub/backend/money"
+	"time"
+)
 
 type Symbol struct {
 	ID         int     `json:"id"`
@@ -11,23 +14,33 @@ type Symbol struct {
 }
 
 type SpinRequest struct {
-	PlayerID  string `json:"playerId"`
-	BetAmount int64  `json:"betAmount"`
+	PlayerID  string       `json:"playerId"`
+	BetAmount money.Amount `json:"betAmount"`
+	Currency  string       `json:"currency"`
 }
 
 type SpinResult struct {
-	Success    bool    `json:"success"`
-	Symbols    []int   `json:"symbols"`
-	WinAmount  int64   `json:"winAmount"`
-	NewBalance int64    `json:"newBalance"`
-	WinType    string  `json:"winType"`
-	JackpotWin bool    `json:"jackpotWin"`
-	Message    string  `json:"message"`
-	Multiplier float64 `json:"multiplier"`
+	Success         bool                `json:"success"`
+	Symbols         []int               `json:"symbols"`
+	WinAmount       money.Amount        `json:"winAmount"`
+	NewBalance      money.Amount        `json:"newBalance"`
+	Currency        string              `json:"currency"`
+	WinType         string              `json:"winType"`
+	JackpotWin      bool                `json:"jackpotWin"`
+	Message         string              `json:"message"`
+	Multiplier      float64             `json:"multiplier"`
+	MysteryJackpots []MysteryJackpotWin `json:"mysteryJackpots,omitempty"`
+	RealityCheckDue bool                `json:"realityCheckDue"`
 }
 
+// JackpotInfo is a jackpot pool as players see it. The last win fields are
+// empty until the pool has been won.
 type JackpotInfo struct {
-	Amount      int64     `json:"amount"`
-	LastWinner  string    `json:"lastWinner"`
-	LastwinTime time.Time `json:"lastWinTime"`
+	Pool        string       `json:"pool"`
+	Currency    string       `json:"currency"`
+	Amount      money.Amount `json:"amount"`
+	LastWinner  string       `json:"lastWinner"`
+	LastWinTime *time.Time   `json:"lastWinTime"`
+	// LastWinAmount is what the last winner was paid.
+	LastWinAmount money.Amount `json:"lastWinAmount"`
 }
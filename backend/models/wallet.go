@@ -0,0 +1,45 @@
+package models
+
+import (
+	"casino-hub/backend/money"
+	"time"
+)
+
+type BonusGrant struct {
+	ID               int64        `json:"id"`
+	Currency         string       `json:"currency"`
+	Source           string       `json:"source"`
+	Amount           money.Amount `json:"amount"`
+	Remaining        money.Amount `json:"remaining"`
+	WageringRequired money.Amount `json:"wageringRequired"`
+	Wagered          money.Amount `json:"wagered"`
+	Progress         float64      `json:"progress"` // 0-1
+	Status           string       `json:"status"`   // active, converted, exhausted
+	CreatedAt        time.Time    `json:"createdAt"`
+	CompletedAt      *time.Time   `json:"completedAt,omitempty"`
+}
+
+// WalletSummary holds the balances of one currency.
+type WalletSummary struct {
+	Currency   string       `json:"currency"`
+	Name       string       `json:"name"`
+	Cash       money.Amount `json:"cash"`
+	Bonus      money.Amount `json:"bonus"`
+	Total      money.Amount `json:"total"`
+	Redeemable bool         `json:"redeemable"`
+	Bonuses    []BonusGrant `json:"bonuses"`
+}
+
+type Wallet struct {
+	SpendOrder string          `json:"spendOrder"`
+	Currencies []WalletSummary `json:"currencies"`
+}
+
+type Redemption struct {
+	ID        int64        `json:"id"`
+	Currency  string       `json:"currency"`
+	Amount    money.Amount `json:"amount"`
+	Status    string       `json:"status"` // pending, paid, rejected
+	Balance   money.Amount `json:"balance"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
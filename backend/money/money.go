@@ -0,0 +1,132 @@
+// Package money holds amounts of coins as integers in minor units.
+//
+// One coin is Scale minor units, so every stored balance, stake and payout is
+// exact. Amounts are encoded in JSON as decimal numbers of whole coins
+// ("12.50"); JSON input with more precision than one minor unit is rejected
+// rather than rounded.
+//
+// Rounding policy: the only operation that can produce a fraction of a minor
+// unit is MulFrac, used for odds such as 3:2 blackjack or the 0.95 banker
+// payout. It rounds half to even, so over many rounds neither the player nor
+// the house is favoured by rounding.
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Scale is the number of minor units in one coin.
+const Scale = 100
+
+type Amount int64
+
+// Coins returns n whole coins.
+func Coins(n int64) Amount {
+	return Amount(n * Scale)
+}
+
+// Times multiplies an amount by a whole number, which is always exact.
+func (a Amount) Times(n int64) Amount {
+	return a * Amount(n)
+}
+
+// MulFrac returns a*num/den rounded half to even. It panics if den is zero.
+func (a Amount) MulFrac(num, den int64) Amount {
+	if den == 0 {
+		panic("money: division by zero")
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	p := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(num))
+	q, r := new(big.Int).QuoRem(p, big.NewInt(den), new(big.Int))
+
+	// Compare twice the remainder with the divisor to decide the rounding.
+	r2 := new(big.Int).Abs(r)
+	r2.Lsh(r2, 1)
+	switch c := r2.Cmp(big.NewInt(den)); {
+	case c > 0, c == 0 && q.Bit(0) == 1:
+		if p.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Amount(q.Int64())
+}
+
+// Major returns the amount in whole coins, dropping any fraction.
+func (a Amount) Major() int64 {
+	return int64(a) / Scale
+}
+
+// String formats the amount in coins with two decimals, e.g. "-3.05".
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/Scale, v%Scale)
+}
+
+// Parse reads a decimal number of coins such as "12", "12.5" or "1e3". More
+// precision than one minor unit is an error.
+func Parse(s string) (Amount, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(Scale))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("money: %q has more than two decimals", s)
+	}
+	if !r.Num().IsInt64() {
+		return 0, fmt.Errorf("money: %q is out of range", s)
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Value and Scan store amounts as their minor units.
+
+func (a Amount) Value() (driver.Value, error) {
+	return int64(a), nil
+}
+
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		*a = Amount(v)
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+		*a = Amount(n)
+	case nil:
+		*a = 0
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
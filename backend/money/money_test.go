@@ -0,0 +1,144 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMulFrac(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        Amount
+		num, den int64
+		want     Amount
+	}{
+		{"exact", 1000, 3, 2, 1500},
+		{"half rounds down to even", 5, 1, 2, 2},
+		{"half rounds up to even", 7, 1, 2, 4},
+		{"below half", 101, 1, 3, 34},
+		{"above half", 102, 2, 3, 68},
+		{"banker commission", 1001, 95, 100, 951},
+		{"banker commission half", 1010, 95, 100, 960},
+		{"negative half to even", -5, 1, 2, -2},
+		{"negative half up to even", -7, 1, 2, -4},
+		{"negative above half", -102, 2, 3, -68},
+		{"negative denominator", 7, 1, -2, -4},
+		{"zero", 0, 95, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.MulFrac(tt.num, tt.den); got != tt.want {
+				t.Errorf("%d.MulFrac(%d, %d) = %d, want %d", tt.a, tt.num, tt.den, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulFracZeroDenominator(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MulFrac with a zero denominator did not panic")
+		}
+	}()
+	Amount(1).MulFrac(1, 0)
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		a    Amount
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1250, "12.50"},
+		{-305, "-3.05"},
+		{-5, "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "12", want: 1200},
+		{in: "12.5", want: 1250},
+		{in: "12.50", want: 1250},
+		{in: "-3.05", want: -305},
+		{in: "1e3", want: 100000},
+		{in: "0.001", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1e30", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type payload struct {
+		Amount Amount `json:"amount"`
+	}
+	for _, a := range []Amount{0, 1, 99, 1250, -305, Coins(500000)} {
+		b, err := json.Marshal(payload{a})
+		if err != nil {
+			t.Fatalf("Marshal(%d): %v", a, err)
+		}
+		var got payload
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", b, err)
+		}
+		if got.Amount != a {
+			t.Errorf("round trip of %d through %s gave %d", a, b, got.Amount)
+		}
+	}
+
+	b, _ := json.Marshal(payload{1250})
+	if string(b) != `{"amount":12.50}` {
+		t.Errorf("Marshal(1250) = %s, want {\"amount\":12.50}", b)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: `{"amount":7.25}`, want: 725},
+		{in: `{"amount":-1}`, want: -100},
+		{in: `{"amount":null}`, want: 0},
+		{in: `{"amount":0.125}`, wantErr: true},
+		{in: `{"amount":"12"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got struct {
+			Amount Amount `json:"amount"`
+		}
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %d, want an error", tt.in, got.Amount)
+			}
+			continue
+		}
+		if err != nil || got.Amount != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, %v, want %d", tt.in, got.Amount, err, tt.want)
+		}
+	}
+}
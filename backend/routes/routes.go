@@ -14,7 +14,7 @@ func RegisterRoutes(r *mux.Router) {
 	api.HandleFunc("/signup", handlers.Signup).Methods("POST")
 	api.HandleFunc("/login", handlers.Login).Methods("POST")
 	api.HandleFunc("/logout", handlers.Logout).Methods("POST")
-	api.Handle("/contact", handlers.AuthMiddleWare(http.HandlerFunc(handlers.ContactHandler))).Methods("POST")
+	api.Handle("/contact", handlers.AuthMiddleWare(handlers.IdempotencyMiddleware(http.HandlerFunc(handlers.ContactHandler)))).Methods("POST")
 	api.HandleFunc("/forgot-password", handlers.ForgotPasswordHandler).Methods("POST")
 	api.HandleFunc("/reset-password", handlers.ResetPassword).Methods("POST")
 
@@ -22,58 +22,124 @@ func RegisterRoutes(r *mux.Router) {
 	//Balance
 	balance := r.PathPrefix("/api/v1").Subrouter()
 	balance.Use(handlers.AuthMiddleWare)
+	balance.Use(handlers.IdempotencyMiddleware)
 	balance.HandleFunc("/balance", handlers.GetBalance).Methods("GET")
-	balance.HandleFunc("/balance", handlers.UpdateBalance).Methods("PUT")
 
     // protected user routes
     user := api.PathPrefix("/users").Subrouter()
     user.Use(handlers.AuthMiddleWare)
+    user.Use(handlers.IdempotencyMiddleware)
     user.HandleFunc("/profile", handlers.GetProfile).Methods("GET")
 	user.HandleFunc("/profile", handlers.UpdateProfile).Methods("PUT")
 
 	// slot
 	slot := api.PathPrefix("/slot").Subrouter()
 	slot.Use(handlers.AuthMiddleWare)
+	slot.Use(handlers.IdempotencyMiddleware)
 	slot.HandleFunc("/spin", handlers.SpinSlot).Methods("POST")
 
 	//blackJack
 	blackjack := api.PathPrefix("/blackjack").Subrouter()
 	blackjack.Use(handlers.AuthMiddleWare)
+	blackjack.Use(handlers.IdempotencyMiddleware)
+	blackjack.HandleFunc("/tables", handlers.ListBlackjackTables).Methods("GET")
 	blackjack.HandleFunc("/start", handlers.StartGameHandler).Methods("POST")
 	blackjack.HandleFunc("/hit", handlers.HitHandler).Methods("POST")
 	blackjack.HandleFunc("/stand", handlers.StandHandler).Methods("POST")
+	blackjack.HandleFunc("/double", handlers.DoubleHandler).Methods("POST")
+	blackjack.HandleFunc("/split", handlers.SplitHandler).Methods("POST")
+	blackjack.HandleFunc("/insurance", handlers.InsuranceHandler).Methods("POST")
+	blackjack.HandleFunc("/surrender", handlers.SurrenderHandler).Methods("POST")
 
 	//baccarat
 	baccarat := api.PathPrefix("/baccarat").Subrouter()
 	baccarat.Use(handlers.AuthMiddleWare)
+	baccarat.Use(handlers.IdempotencyMiddleware)
 	baccarat.HandleFunc("/play", handlers.PlayBaccarat).Methods("POST")
+	baccarat.HandleFunc("/roads", handlers.GetBaccaratRoads).Methods("GET")
 
 	//progressiveSlot
 	progressiveSlot := api.PathPrefix("/progressiveSlot").Subrouter()
 	progressiveSlot.Use(handlers.AuthMiddleWare)
+	progressiveSlot.Use(handlers.IdempotencyMiddleware)
 	progressiveSlot.HandleFunc("/play", handlers.ProgressiveSlotHandler).Methods("POST")
+	progressiveSlot.HandleFunc("/jackpot", handlers.GetProgressiveJackpot).Methods("GET")
 	http.Handle("/api/progressiveSlot", handlers.RecoverMiddleware(http.HandlerFunc(handlers.ProgressiveSlotHandler)))
 
 	//keno
 	keno := api.PathPrefix("/keno").Subrouter()
 	keno.Use(handlers.AuthMiddleWare)
+	keno.Use(handlers.IdempotencyMiddleware)
 	keno.HandleFunc("/play", handlers.PlayKeno).Methods("POST")
+	keno.HandleFunc("/jackpot", handlers.GetKenoJackpot).Methods("GET")
+	keno.HandleFunc("/tickets", handlers.BuyKenoTickets).Methods("POST")
+	keno.HandleFunc("/tickets", handlers.GetKenoTickets).Methods("GET")
+	keno.HandleFunc("/draws", handlers.GetKenoDraws).Methods("GET")
+	keno.HandleFunc("/draws/next", handlers.GetNextKenoDraw).Methods("GET")
+	keno.HandleFunc("/sets", handlers.GetKenoSets).Methods("GET")
+	keno.HandleFunc("/sets", handlers.CreateKenoSet).Methods("POST")
+	keno.HandleFunc("/sets/{id}", handlers.GetKenoSet).Methods("GET")
+	keno.HandleFunc("/sets/{id}", handlers.UpdateKenoSet).Methods("PUT")
+	keno.HandleFunc("/sets/{id}", handlers.DeleteKenoSet).Methods("DELETE")
 
 	//hilo
 	hilo := api.PathPrefix("/hilo").Subrouter()
 	hilo.Use(handlers.AuthMiddleWare)
+	hilo.Use(handlers.IdempotencyMiddleware)
+	hilo.HandleFunc("/round", handlers.GetHiLoRound).Methods("GET")
+	hilo.HandleFunc("/start", handlers.StartHiLo).Methods("POST")
 	hilo.HandleFunc("/play", handlers.PlayHiLo).Methods("POST")
+	hilo.HandleFunc("/cashout", handlers.CashOutHiLo).Methods("POST")
 
 	//roulette
 	roulette := api.PathPrefix("/roulette").Subrouter()
 	roulette.Use(handlers.AuthMiddleWare)
+	roulette.Use(handlers.IdempotencyMiddleware)
 	roulette.HandleFunc("/spin", handlers.SpinRoulette).Methods("POST")
+	roulette.HandleFunc("/variants", handlers.ListRouletteVariants).Methods("GET")
+	roulette.HandleFunc("/history", handlers.GetRouletteHistory).Methods("GET")
+
+	//jackpots
+	jackpots := api.PathPrefix("/jackpots").Subrouter()
+	jackpots.Use(handlers.AuthMiddleWare)
+	jackpots.HandleFunc("/mystery", handlers.GetMysteryJackpots).Methods("GET")
 
 	//favourites
 	favourites := api.PathPrefix("/favourites").Subrouter()
 	favourites.Use(handlers.AuthMiddleWare)
+	favourites.Use(handlers.IdempotencyMiddleware)
 	favourites.HandleFunc("/toggle", handlers.ToggleFavourite).Methods("POST")
 
+	//wallet
+	walletRoutes := api.PathPrefix("/wallet").Subrouter()
+	walletRoutes.Use(handlers.AuthMiddleWare)
+	walletRoutes.Use(handlers.IdempotencyMiddleware)
+	walletRoutes.HandleFunc("", handlers.GetWallet).Methods("GET")
+	walletRoutes.HandleFunc("/transactions", handlers.GetTransactions).Methods("GET")
+	walletRoutes.HandleFunc("/transactions/export", handlers.ExportTransactions).Methods("GET")
+	walletRoutes.HandleFunc("/redeem", handlers.RedeemWallet).Methods("POST")
+
+	//limits
+	limitRoutes := api.PathPrefix("/limits").Subrouter()
+	limitRoutes.Use(handlers.AuthMiddleWare)
+	limitRoutes.Use(handlers.IdempotencyMiddleware)
+	limitRoutes.HandleFunc("", handlers.GetLimits).Methods("GET")
+	limitRoutes.HandleFunc("", handlers.UpdateLimits).Methods("PUT")
+
+	//self-exclusion
+	exclusionRoutes := api.PathPrefix("/self-exclusion").Subrouter()
+	exclusionRoutes.Use(handlers.AuthMiddleWare)
+	exclusionRoutes.Use(handlers.IdempotencyMiddleware)
+	exclusionRoutes.HandleFunc("", handlers.GetSelfExclusion).Methods("GET")
+	exclusionRoutes.HandleFunc("", handlers.SelfExclude).Methods("POST")
+
+	//session
+	sessionRoutes := api.PathPrefix("/session").Subrouter()
+	sessionRoutes.Use(handlers.AuthMiddleWare)
+	sessionRoutes.Use(handlers.IdempotencyMiddleware)
+	sessionRoutes.HandleFunc("", handlers.GetSession).Methods("GET")
+	sessionRoutes.HandleFunc("/ack", handlers.AcknowledgeRealityCheck).Methods("POST")
+
 	//recent
 	recent := api.PathPrefix("/recent").Subrouter()
 	recent.Use(handlers.AuthMiddleWare)
@@ -82,6 +148,7 @@ func RegisterRoutes(r *mux.Router) {
 	// promotions
 	promotions := api.PathPrefix("/promotions").Subrouter()
 	promotions.Use(handlers.AuthMiddleWare)
+	promotions.Use(handlers.IdempotencyMiddleware)
 	promotions.HandleFunc("/daily-cash", handlers.ClaimDailyCash).Methods("POST")
 	promotions.HandleFunc("/spin-wheel", handlers.SpinWheel).Methods("POST")
 
@@ -0,0 +1,210 @@
+// Package session tracks continuous play. A session starts with the first
+// bet after a break and lasts while bets keep coming in within IdleTimeout of
+// each other.
+//
+// Every RealityCheckInterval of a session the player gets a reality check:
+// the bet that crosses the interval goes through and is flagged, and further
+// bets are refused until the check is acknowledged.
+package session
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/wallet"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	defaultIdleTimeout          = 30 * time.Minute
+	defaultRealityCheckInterval = 60 * time.Minute
+)
+
+// ErrRealityCheckDue is returned for bets placed while a reality check is
+// waiting to be acknowledged.
+var ErrRealityCheckDue = errors.New("session: reality check must be acknowledged")
+
+type Session struct {
+	ID           int64
+	UserID       int
+	StartedAt    time.Time
+	LastActivity time.Time
+	// RealityCheckAt is when the last reality check was acknowledged, or the
+	// session start.
+	RealityCheckAt time.Time
+	// RealityCheckPending is set once a reality check has been reported and
+	// not yet acknowledged.
+	RealityCheckPending bool
+}
+
+// Length is how long the session has been running at now.
+func (s Session) Length(now time.Time) time.Duration {
+	return now.Sub(s.StartedAt)
+}
+
+// realityCheckElapsed reports whether a reality check interval has passed
+// since the last acknowledgement.
+func (s Session) realityCheckElapsed(now time.Time) bool {
+	interval := RealityCheckInterval()
+	return interval > 0 && now.Sub(s.RealityCheckAt) >= interval
+}
+
+// IdleTimeout is the break that ends a session, set with
+// SESSION_IDLE_TIMEOUT (a Go duration such as "30m").
+func IdleTimeout() time.Duration {
+	return durationEnv("SESSION_IDLE_TIMEOUT", defaultIdleTimeout)
+}
+
+// RealityCheckInterval is the stretch of continuous play between reality
+// checks, set with REALITY_CHECK_INTERVAL. Zero turns reality checks off.
+func RealityCheckInterval() time.Duration {
+	return durationEnv("REALITY_CHECK_INTERVAL", defaultRealityCheckInterval)
+}
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		log.Printf("Invalid %s, using default: %s\n", name, v)
+	}
+	return def
+}
+
+// Current returns the user's running session; ok is false when the user is
+// on a break.
+func Current(userID int, now time.Time) (Session, bool, error) {
+	return current(database.DB.QueryRow(lastSession, userID), userID, now)
+}
+
+// CurrentTx is Current inside tx, locking the session row until commit.
+func CurrentTx(tx *sql.Tx, userID int, now time.Time) (Session, bool, error) {
+	return current(tx.QueryRow(lastSession+" FOR UPDATE", userID), userID, now)
+}
+
+const lastSession = `
+	SELECT id, started_at, last_activity_at, COALESCE(reality_check_at, started_at), reality_check_pending
+	FROM play_sessions
+	WHERE user_id = ? ORDER BY id DESC LIMIT 1`
+
+func current(row *sql.Row, userID int, now time.Time) (Session, bool, error) {
+	s := Session{UserID: userID}
+	err := row.Scan(&s.ID, &s.StartedAt, &s.LastActivity, &s.RealityCheckAt, &s.RealityCheckPending)
+	if err == sql.ErrNoRows {
+		return Session{}, false, nil
+	}
+	if err != nil {
+		return Session{}, false, err
+	}
+	if now.Sub(s.LastActivity) >= IdleTimeout() {
+		return Session{}, false, nil
+	}
+	return s, true, nil
+}
+
+// TouchTx records a bet at now, extending the running session or starting a
+// new one. It refuses the bet with ErrRealityCheckDue while a reality check is
+// waiting to be acknowledged; the bet that crosses the interval is accepted
+// and marks the check pending, which the returned session reports.
+func TouchTx(tx *sql.Tx, userID int, now time.Time) (Session, error) {
+	s, ok, err := CurrentTx(tx, userID, now)
+	if err != nil {
+		return Session{}, err
+	}
+	if !ok {
+		res, err := tx.Exec("INSERT INTO play_sessions (user_id, started_at, last_activity_at) VALUES (?, ?, ?)", userID, now, now)
+		if err != nil {
+			return Session{}, err
+		}
+		id, _ := res.LastInsertId()
+		return Session{ID: id, UserID: userID, StartedAt: now, LastActivity: now, RealityCheckAt: now}, nil
+	}
+
+	if s.RealityCheckPending {
+		return s, ErrRealityCheckDue
+	}
+	s.LastActivity = now
+	s.RealityCheckPending = s.realityCheckElapsed(now)
+	_, err = tx.Exec("UPDATE play_sessions SET last_activity_at = ?, reality_check_pending = ? WHERE id = ?", now, s.RealityCheckPending, s.ID)
+	return s, err
+}
+
+// Acknowledge clears a pending reality check and restarts the interval. It
+// returns the report the player acknowledged.
+func Acknowledge(userID int) (models.RealityCheck, error) {
+	now := time.Now()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return models.RealityCheck{}, err
+	}
+	defer tx.Rollback()
+
+	s, ok, err := CurrentTx(tx, userID, now)
+	if err != nil {
+		return models.RealityCheck{}, err
+	}
+	if !ok {
+		return models.RealityCheck{Net: []models.SessionNet{}}, nil
+	}
+	report, err := report(tx, s, now)
+	if err != nil {
+		return models.RealityCheck{}, err
+	}
+	if _, err := tx.Exec("UPDATE play_sessions SET reality_check_at = ?, reality_check_pending = 0 WHERE id = ?", now, s.ID); err != nil {
+		return models.RealityCheck{}, err
+	}
+	report.Due = false
+	return report, tx.Commit()
+}
+
+// Report describes the user's running session for a reality check.
+func Report(userID int) (models.RealityCheck, error) {
+	now := time.Now()
+	s, ok, err := Current(userID, now)
+	if err != nil || !ok {
+		return models.RealityCheck{Net: []models.SessionNet{}}, err
+	}
+	return report(database.DB, s, now)
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func report(db querier, s Session, now time.Time) (models.RealityCheck, error) {
+	started := s.StartedAt
+	r := models.RealityCheck{
+		Active:           true,
+		SessionStartedAt: &started,
+		SessionMinutes:   int(s.Length(now) / time.Minute),
+		Due:              s.RealityCheckPending,
+		Net:              []models.SessionNet{},
+	}
+	if interval := RealityCheckInterval(); interval > 0 {
+		next := s.RealityCheckAt.Add(interval)
+		r.NextCheckAt = &next
+	}
+
+	// Net result of game rounds since the session started, per currency.
+	rows, err := db.Query(`
+		SELECT currency, COALESCE(SUM(IF(type = 'credit', amount, -amount)), 0)
+		FROM wallet_transactions
+		WHERE user_id = ? AND account = ? AND game IS NOT NULL AND created_at >= ?
+		GROUP BY currency
+		ORDER BY currency`, s.UserID, wallet.PlayerAccount, s.StartedAt)
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n models.SessionNet
+		if err := rows.Scan(&n.Currency, &n.Net); err != nil {
+			return r, err
+		}
+		r.Net = append(r.Net, n)
+	}
+	return r, rows.Err()
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRealityCheckElapsed(t *testing.T) {
+	start := time.Date(2024, 5, 15, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		interval string
+		checked  time.Duration // after the start
+		now      time.Duration // after the start
+		want     bool
+	}{
+		{"inside the first interval", "", 0, 59 * time.Minute, false},
+		{"first interval up", "", 0, 60 * time.Minute, true},
+		{"well past the interval", "", 0, 5 * time.Hour, true},
+		{"inside the interval after an acknowledgement", "", 60 * time.Minute, 110 * time.Minute, false},
+		{"interval up after an acknowledgement", "", 60 * time.Minute, 120 * time.Minute, true},
+		{"shorter interval", "15m", 0, 15 * time.Minute, true},
+		{"turned off", "0", 0, 5 * time.Hour, false},
+		{"invalid interval", "-15m", 0, 59 * time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REALITY_CHECK_INTERVAL", tt.interval)
+			s := Session{StartedAt: start, RealityCheckAt: start.Add(tt.checked)}
+			if got := s.realityCheckElapsed(start.Add(tt.now)); got != tt.want {
+				t.Errorf("reality check elapsed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionLength(t *testing.T) {
+	start := time.Date(2024, 5, 15, 18, 0, 0, 0, time.UTC)
+	// Acknowledging a reality check does not restart the session.
+	s := Session{StartedAt: start, RealityCheckAt: start.Add(time.Hour)}
+	if got := s.Length(start.Add(90 * time.Minute)); got != 90*time.Minute {
+		t.Errorf("Length = %s, want 1h30m", got)
+	}
+}
+
+func TestDurationSettings(t *testing.T) {
+	tests := []struct {
+		env string
+		get func() time.Duration
+		def time.Duration
+	}{
+		{"SESSION_IDLE_TIMEOUT", IdleTimeout, defaultIdleTimeout},
+		{"REALITY_CHECK_INTERVAL", RealityCheckInterval, defaultRealityCheckInterval},
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			value string
+			want  time.Duration
+		}{
+			{"", tt.def},
+			{"45m", 45 * time.Minute},
+			{"0", 0},
+			{"-1m", tt.def},
+			{"an hour", tt.def},
+		} {
+			t.Setenv(tt.env, c.value)
+			if got := tt.get(); got != c.want {
+				t.Errorf("%s=%q: got %s, want %s", tt.env, c.value, got, c.want)
+			}
+		}
+	}
+}
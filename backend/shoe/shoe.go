@@ -0,0 +1,177 @@
+// Package shoe deals cards from a multi-deck shoe the way a live table does:
+// the decks are shuffled together, a cut card is placed at the penetration
+// point, and once the cut card has come out the shoe is reshuffled before the
+// next round.
+//
+// Each table has one shoe, kept in the shoes table, so consecutive rounds
+// draw from the same shoe. Cards still on the table are never shuffled back
+// in: see InPlay.
+package shoe
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+var Suits = []string{"♠", "♥", "♦", "♣"}
+
+// Card is a card in the shoe. Games map it to their own card type.
+type Card struct {
+	Rank int    `json:"r"` // 1 (ace) to 13 (king)
+	Suit string `json:"s"`
+}
+
+type Shoe struct {
+	Table string
+	// Number counts the shoes dealt at the table; it goes up with every
+	// shuffle.
+	Number int
+	Decks  int
+	Cards  []Card
+	// Position is the index of the next card to deal.
+	Position int
+	// CutCard is the position of the cut card. Reaching it ends the shoe
+	// at the end of the round.
+	CutCard    int
+	ShuffledAt time.Time
+	// InPlay holds the cards dealt to rounds that are not over yet, which a
+	// shuffle leaves out. It is not stored: callers fill it in after LoadTx
+	// with the cards of the table's open rounds, and Draw adds every card it
+	// deals.
+	InPlay []Card
+}
+
+// New returns a freshly shuffled shoe of decks 52-card decks with the cut
+// card placed after penetration (0 to 1) of the cards.
+func New(table string, decks int, penetration float64) *Shoe {
+	s := &Shoe{Table: table, Decks: decks}
+	s.CutCard = int(float64(decks*52) * penetration)
+	s.Shuffle()
+	return s
+}
+
+// fullDecks returns the cards of n decks in order.
+func fullDecks(n int) []Card {
+	cards := make([]Card, 0, n*52)
+	for range n {
+		for _, suit := range Suits {
+			for rank := 1; rank <= 13; rank++ {
+				cards = append(cards, Card{Rank: rank, Suit: suit})
+			}
+		}
+	}
+	return cards
+}
+
+// Shuffle gathers every card that is not in play back into the shoe and
+// shuffles it.
+func (s *Shoe) Shuffle() {
+	held := map[Card]int{}
+	for _, c := range s.InPlay {
+		held[c]++
+	}
+	all := fullDecks(s.Decks)
+	s.Cards = make([]Card, 0, len(all))
+	for _, c := range all {
+		if held[c] > 0 {
+			held[c]--
+			continue
+		}
+		s.Cards = append(s.Cards, c)
+	}
+	// With every card out on abandoned rounds the table could never deal
+	// again, so it starts over from full decks instead.
+	if len(s.Cards) == 0 {
+		s.Cards = all
+	}
+	rand.Shuffle(len(s.Cards), func(i, j int) {
+		s.Cards[i], s.Cards[j] = s.Cards[j], s.Cards[i]
+	})
+	s.Position = 0
+	s.Number++
+	s.ShuffledAt = time.Now()
+}
+
+// CutCardReached reports whether the cut card has come out.
+func (s *Shoe) CutCardReached() bool {
+	return s.Position >= s.CutCard
+}
+
+// NewRound is called before each round is dealt and reshuffles the shoe if
+// the cut card came out during the last one. It reports whether it did.
+func (s *Shoe) NewRound() bool {
+	if !s.CutCardReached() {
+		return false
+	}
+	s.Shuffle()
+	return true
+}
+
+// Draw deals the next card. A round that runs through the whole shoe carries
+// on from a reshuffled one, without the cards already in play.
+func (s *Shoe) Draw() Card {
+	if s.Position >= len(s.Cards) {
+		s.Shuffle()
+	}
+	c := s.Cards[s.Position]
+	s.Position++
+	s.InPlay = append(s.InPlay, c)
+	return c
+}
+
+// Remaining is the number of cards left before the end of the shoe.
+func (s *Shoe) Remaining() int {
+	return len(s.Cards) - s.Position
+}
+
+// LoadTx returns the table's shoe, locked until tx ends. A table without a
+// shoe, or whose shoe has a different number of decks, gets a new one; it is
+// only stored by SaveTx.
+func LoadTx(tx *sql.Tx, table string, decks int, penetration float64) (*Shoe, error) {
+	// An empty placeholder row gives concurrent first rounds a row to wait
+	// on.
+	now := time.Now()
+	_, err := tx.Exec(`
+		INSERT IGNORE INTO shoes (table_id, shoe_number, decks, cards, position, cut_card, shuffled_at, updated_at)
+		VALUES (?, 0, 0, '[]', 0, 0, ?, ?)`, table, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Shoe{Table: table}
+	var cards []byte
+	err = tx.QueryRow(`
+		SELECT shoe_number, decks, cards, position, cut_card, shuffled_at
+		FROM shoes
+		WHERE table_id = ?
+		FOR UPDATE`, table).Scan(&s.Number, &s.Decks, &cards, &s.Position, &s.CutCard, &s.ShuffledAt)
+	if err != nil {
+		return nil, err
+	}
+	if s.Decks != decks {
+		fresh := New(table, decks, penetration)
+		fresh.Number = s.Number + 1
+		return fresh, nil
+	}
+	if err := json.Unmarshal(cards, &s.Cards); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SaveTx stores the shoe for the next round at its table.
+func (s *Shoe) SaveTx(tx *sql.Tx) error {
+	cards, err := json.Marshal(s.Cards)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO shoes (table_id, shoe_number, decks, cards, position, cut_card, shuffled_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE shoe_number = VALUES(shoe_number), decks = VALUES(decks), cards = VALUES(cards), position = VALUES(position),
+			cut_card = VALUES(cut_card), shuffled_at = VALUES(shuffled_at), updated_at = VALUES(updated_at)`,
+		s.Table, s.Number, s.Decks, cards, s.Position, s.CutCard, s.ShuffledAt, time.Now())
+	return err
+}
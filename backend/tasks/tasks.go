@@ -2,22 +2,119 @@ package tasks
 
 import (
 	"casino-hub/backend/database"
+	"casino-hub/backend/handlers"
+	"casino-hub/backend/keno"
+	"casino-hub/backend/money"
+	"casino-hub/backend/wallet"
 	"log"
 	"time"
 )
 
+var dailyFreeCoins = money.Coins(1500)
+
 func AddDailyFreeCoins() {
 	ticker := time.NewTicker(1 * time.Hour)
 	for range ticker.C {
-		res, err := database.DB.Exec(`UPDATE users 
-		SET balance = balance + 1500, last_free_coins = NOW() 
-		WHERE last_free_coins IS NULL OR last_free_coins < DATE_SUB(NOW(), INTERVAL 24 HOUR)`)
-
+		// Self-excluded players get no promotional coins.
+		now := time.Now()
+		rows, err := database.DB.Query(`SELECT id FROM users u
+		WHERE (last_free_coins IS NULL OR last_free_coins < DATE_SUB(NOW(), INTERVAL 24 HOUR))
+		AND NOT EXISTS (
+			SELECT 1 FROM self_exclusions e
+			WHERE e.user_id = u.id AND e.starts_at <= ? AND (e.ends_at IS NULL OR e.ends_at > ?)
+		)`, now, now)
 		if err != nil {
 			log.Println("❌ Error updating free coins:", err)
-		} else {
-			rows, _ := res.RowsAffected()
-			log.Printf("✅ Daily coins added to %d users\n", rows)
+			continue
+		}
+		var userIDs []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err == nil {
+				userIDs = append(userIDs, id)
+			}
+		}
+		rows.Close()
+
+		credited := 0
+		for _, id := range userIDs {
+			if err := creditFreeCoins(id); err != nil {
+				log.Printf("❌ Error adding free coins to user %d: %v\n", id, err)
+				continue
+			}
+			credited++
+		}
+		log.Printf("✅ Daily coins added to %d users\n", credited)
+	}
+}
+
+func creditFreeCoins(userID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Re-check under the row lock so a slow tick never pays twice.
+	res, err := tx.Exec(`UPDATE users SET last_free_coins = NOW()
+		WHERE id = ? AND (last_free_coins IS NULL OR last_free_coins < DATE_SUB(NOW(), INTERVAL 24 HOUR))`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	_, err = wallet.GrantBonusTx(tx, userID, wallet.GoldCoins, dailyFreeCoins, wallet.ReasonDailyFreeCoins)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func PurgeExpiredIdempotencyKeys() {
+	ticker := time.NewTicker(1 * time.Hour)
+	for range ticker.C {
+		res, err := database.DB.Exec("DELETE FROM idempotency_keys WHERE expires_at < NOW()")
+		if err != nil {
+			log.Println("❌ Error purging idempotency keys:", err)
+			continue
+		}
+		rows, _ := res.RowsAffected()
+		log.Printf("✅ Purged %d expired idempotency keys\n", rows)
+	}
+}
+
+// RunKenoDraws draws the live Keno draws as they fall due and keeps the next
+// one scheduled, so there is always a draw to buy tickets for.
+func RunKenoDraws() {
+	ticker := time.NewTicker(10 * time.Second)
+	for range ticker.C {
+		now := time.Now()
+		ran, err := keno.RunDueDraws(now)
+		if err != nil {
+			log.Println("❌ Error running keno draws:", err)
+		}
+		if ran > 0 {
+			log.Printf("✅ Ran %d keno draws\n", ran)
+		}
+		if _, err := keno.NextDraw(now); err != nil {
+			log.Println("❌ Error scheduling keno draw:", err)
+		}
+	}
+}
+
+// ExpireBlackjackRounds finishes blackjack rounds the player walked away
+// from, so their cards go back into the table's shoe.
+func ExpireBlackjackRounds() {
+	ticker := time.NewTicker(1 * time.Minute)
+	for range ticker.C {
+		expired, err := handlers.ExpireBlackjackRounds(time.Now())
+		if err != nil {
+			log.Println("❌ Error expiring blackjack rounds:", err)
+		}
+		if expired > 0 {
+			log.Printf("✅ Expired %d blackjack rounds\n", expired)
 		}
 	}
 }
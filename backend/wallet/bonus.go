@@ -0,0 +1,293 @@
+package wallet
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Bonus grants. Promotional credits land in the bonus sub-wallet as a grant
+// that must be wagered WageringMultiplier times before whatever is left of it
+// converts to cash. Grants are worked off oldest first.
+
+const (
+	SpendCashFirst  = "cash_first"
+	SpendBonusFirst = "bonus_first"
+
+	GrantActive    = "active"
+	GrantConverted = "converted"
+	GrantExhausted = "exhausted"
+
+	defaultWageringMultiplier = 20
+)
+
+// SpendOrder decides which sub-wallet pays for a stake first. It is set with
+// BONUS_SPEND_ORDER and defaults to cash first.
+func SpendOrder() string {
+	if os.Getenv("BONUS_SPEND_ORDER") == SpendBonusFirst {
+		return SpendBonusFirst
+	}
+	return SpendCashFirst
+}
+
+// WageringMultiplier is how many times a grant has to be wagered before it
+// converts, set with BONUS_WAGERING_MULTIPLIER.
+func WageringMultiplier() int64 {
+	if v := os.Getenv("BONUS_WAGERING_MULTIPLIER"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			return n
+		}
+		log.Println("Invalid BONUS_WAGERING_MULTIPLIER, using default:", v)
+	}
+	return defaultWageringMultiplier
+}
+
+// GrantBonusTx credits promotional funds to the bonus sub-wallet of a
+// currency and opens a grant tracking their wagering requirement.
+func GrantBonusTx(tx *sql.Tx, userID int, currency string, amount money.Amount, reason string) (Transaction, error) {
+	t, err := PostTx(tx, Entry{UserID: userID, Type: Credit, Amount: amount, Currency: currency, Reason: reason, SubWallet: SubWalletBonus, Contra: PromotionsAccount})
+	if err != nil {
+		return t, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO bonus_grants (user_id, currency, source, amount, remaining, wagering_required)
+		VALUES (?, ?, ?, ?, ?, ?)`, userID, t.Currency, reason, amount, amount, amount.Times(WageringMultiplier()))
+	return t, err
+}
+
+// splitStake returns how much of a stake is paid from cash and from bonus.
+func splitStake(stake, cash, bonus money.Amount) (money.Amount, money.Amount) {
+	if SpendOrder() == SpendBonusFirst {
+		fromBonus := min(stake, bonus)
+		return stake - fromBonus, fromBonus
+	}
+	fromCash := min(stake, cash)
+	return fromCash, stake - fromCash
+}
+
+// splitPayout pays winnings back in proportion to how the stake was funded,
+// so bonus-funded bets cannot launder bonus money into cash.
+func splitPayout(payout, stake, bonusStake money.Amount) (money.Amount, money.Amount) {
+	if bonusStake <= 0 || stake <= 0 {
+		return payout, 0
+	}
+	toBonus := payout.MulFrac(int64(bonusStake), int64(stake))
+	return payout - toBonus, toBonus
+}
+
+type activeGrant struct {
+	ID        int64
+	Remaining money.Amount
+	Required  money.Amount
+	Wagered   money.Amount
+}
+
+func lockActiveGrants(tx *sql.Tx, userID int, currency string) ([]activeGrant, error) {
+	rows, err := tx.Query(`
+		SELECT id, remaining, wagering_required, wagered
+		FROM bonus_grants
+		WHERE user_id = ? AND currency = ? AND status = ?
+		ORDER BY id
+		FOR UPDATE`, userID, currency, GrantActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []activeGrant
+	for rows.Next() {
+		var g activeGrant
+		if err := rows.Scan(&g.ID, &g.Remaining, &g.Required, &g.Wagered); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// consumeBonusTx draws amount from the remaining funds of active grants.
+func consumeBonusTx(tx *sql.Tx, userID int, currency string, amount money.Amount) error {
+	grants, err := lockActiveGrants(tx, userID, currency)
+	if err != nil {
+		return err
+	}
+	for _, g := range grants {
+		if amount == 0 {
+			break
+		}
+		take := min(amount, g.Remaining)
+		if take == 0 {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE bonus_grants SET remaining = remaining - ? WHERE id = ?", take, g.ID); err != nil {
+			return err
+		}
+		amount -= take
+	}
+	return nil
+}
+
+// creditBonusTx returns bonus winnings to the oldest active grant. With no
+// grant left to hold them they are paid as cash.
+func creditBonusTx(tx *sql.Tx, e Entry) (Transaction, error) {
+	var grantID int64
+	err := tx.QueryRow("SELECT id FROM bonus_grants WHERE user_id = ? AND currency = ? AND status = ? ORDER BY id LIMIT 1 FOR UPDATE", e.UserID, e.Currency, GrantActive).Scan(&grantID)
+	if err == sql.ErrNoRows {
+		e.SubWallet = SubWalletCash
+		return PostTx(tx, e)
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if _, err := tx.Exec("UPDATE bonus_grants SET remaining = remaining + ? WHERE id = ?", e.Amount, grantID); err != nil {
+		return Transaction{}, err
+	}
+	e.SubWallet = SubWalletBonus
+	return PostTx(tx, e)
+}
+
+// recordWageringTx counts a stake towards the oldest grants, converts the
+// ones whose requirement is met and closes the ones that ran out of funds.
+func recordWageringTx(tx *sql.Tx, userID int, currency string, stake money.Amount) error {
+	grants, err := lockActiveGrants(tx, userID, currency)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range grants {
+		if stake > 0 {
+			stake = g.wager(stake)
+			if _, err := tx.Exec("UPDATE bonus_grants SET wagered = ? WHERE id = ?", g.Wagered, g.ID); err != nil {
+				return err
+			}
+		}
+
+		switch g.status() {
+		case GrantConverted:
+			if err := convertGrantTx(tx, userID, currency, g); err != nil {
+				return err
+			}
+		case GrantExhausted:
+			if _, err := tx.Exec("UPDATE bonus_grants SET status = ?, completed_at = NOW() WHERE id = ?", GrantExhausted, g.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// wager counts as much of stake towards the grant as its requirement still
+// needs and returns the rest, for the next grant.
+func (g *activeGrant) wager(stake money.Amount) money.Amount {
+	progress := min(stake, max(g.Required-g.Wagered, 0))
+	g.Wagered += progress
+	return stake - progress
+}
+
+// status is what the grant should become: converted once its requirement is
+// met, exhausted once its funds ran out before that, otherwise still active.
+func (g activeGrant) status() string {
+	switch {
+	case g.Wagered >= g.Required:
+		return GrantConverted
+	case g.Remaining == 0:
+		return GrantExhausted
+	}
+	return GrantActive
+}
+
+func convertGrantTx(tx *sql.Tx, userID int, currency string, g activeGrant) error {
+	if g.Remaining > 0 {
+		// Never move more than the bonus sub-wallet actually holds.
+		var bonus money.Amount
+		if err := tx.QueryRow("SELECT bonus_balance FROM wallet_balances WHERE user_id = ? AND currency = ?", userID, currency).Scan(&bonus); err != nil {
+			return err
+		}
+		amount := min(g.Remaining, bonus)
+		if amount > 0 {
+			if _, err := PostTx(tx, Entry{UserID: userID, Type: Debit, Amount: amount, Currency: currency, Reason: ReasonBonusConvert, SubWallet: SubWalletBonus, Contra: PromotionsAccount}); err != nil {
+				return err
+			}
+			if _, err := PostTx(tx, Entry{UserID: userID, Type: Credit, Amount: amount, Currency: currency, Reason: ReasonBonusConvert, SubWallet: SubWalletCash, Contra: PromotionsAccount}); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := tx.Exec("UPDATE bonus_grants SET status = ?, remaining = 0, completed_at = NOW() WHERE id = ?", GrantConverted, g.ID)
+	return err
+}
+
+// Grants lists a user's bonus grants, newest first. With activeOnly set it
+// returns only the ones still being wagered.
+func Grants(userID int, activeOnly bool) ([]models.BonusGrant, error) {
+	query := `
+		SELECT id, currency, source, amount, remaining, wagering_required, wagered, status, created_at, completed_at
+		FROM bonus_grants WHERE user_id = ?`
+	args := []any{userID}
+	if activeOnly {
+		query += " AND status = ?"
+		args = append(args, GrantActive)
+	}
+	rows, err := database.DB.Query(query+" ORDER BY id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []models.BonusGrant{}
+	for rows.Next() {
+		var g models.BonusGrant
+		var completedAt sql.NullTime
+		if err := rows.Scan(&g.ID, &g.Currency, &g.Source, &g.Amount, &g.Remaining, &g.WageringRequired, &g.Wagered, &g.Status, &g.CreatedAt, &completedAt); err != nil {
+			return nil, err
+		}
+		if completedAt.Valid {
+			t := completedAt.Time
+			g.CompletedAt = &t
+		}
+		g.Progress = 1
+		if g.WageringRequired > 0 {
+			g.Progress = min(float64(g.Wagered)/float64(g.WageringRequired), 1)
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// Summary reports both sub-wallets of every currency together with their
+// active grants.
+func Summary(userID int) (models.Wallet, error) {
+	grants, err := Grants(userID, true)
+	if err != nil {
+		return models.Wallet{}, err
+	}
+
+	summary := models.Wallet{SpendOrder: SpendOrder(), Currencies: []models.WalletSummary{}}
+	for _, code := range CurrencyCodes {
+		cash, bonus, err := Balances(userID, code)
+		if err != nil {
+			return models.Wallet{}, err
+		}
+		c := Currencies[code]
+		ws := models.WalletSummary{
+			Currency:   code,
+			Name:       c.Name,
+			Cash:       cash,
+			Bonus:      bonus,
+			Total:      cash + bonus,
+			Redeemable: c.Redeemable,
+			Bonuses:    []models.BonusGrant{},
+		}
+		for _, g := range grants {
+			if g.Currency == code {
+				ws.Bonuses = append(ws.Bonuses, g)
+			}
+		}
+		summary.Currencies = append(summary.Currencies, ws)
+	}
+	return summary, nil
+}
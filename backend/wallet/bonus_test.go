@@ -0,0 +1,162 @@
+package wallet
+
+import (
+	"casino-hub/backend/money"
+	"testing"
+)
+
+func TestSplitStake(t *testing.T) {
+	tests := []struct {
+		order               string
+		stake, cash, bonus  money.Amount
+		fromCash, fromBonus money.Amount
+	}{
+		{SpendCashFirst, 500, 1000, 1000, 500, 0},
+		{SpendCashFirst, 500, 200, 1000, 200, 300},
+		{SpendCashFirst, 500, 0, 1000, 0, 500},
+		{SpendCashFirst, 500, 500, 0, 500, 0},
+		{SpendBonusFirst, 500, 1000, 1000, 0, 500},
+		{SpendBonusFirst, 500, 1000, 200, 300, 200},
+		{SpendBonusFirst, 500, 1000, 0, 500, 0},
+		// Anything else falls back to cash first.
+		{"", 500, 200, 1000, 200, 300},
+		{"bonus", 500, 200, 1000, 200, 300},
+	}
+	for _, tt := range tests {
+		t.Setenv("BONUS_SPEND_ORDER", tt.order)
+		fromCash, fromBonus := splitStake(tt.stake, tt.cash, tt.bonus)
+		if fromCash != tt.fromCash || fromBonus != tt.fromBonus {
+			t.Errorf("%q: %s from %s cash and %s bonus = %s + %s, want %s + %s",
+				tt.order, tt.stake, tt.cash, tt.bonus, fromCash, fromBonus, tt.fromCash, tt.fromBonus)
+		}
+	}
+}
+
+func TestSplitPayout(t *testing.T) {
+	tests := []struct {
+		name                 string
+		payout, stake, bonus money.Amount
+		wantCash, wantBonus  money.Amount
+	}{
+		{"all cash", 2000, 1000, 0, 2000, 0},
+		{"all bonus", 2000, 1000, 1000, 0, 2000},
+		{"part bonus", 2000, 1000, 250, 1500, 500},
+		{"nothing won", 0, 1000, 250, 0, 0},
+		// Odd cents round half to even on the bonus side.
+		{"rounded", 3, 2, 1, 1, 2},
+		{"rounded down", 5, 4, 1, 4, 1},
+		{"no stake", 2000, 0, 0, 2000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cash, bonus := splitPayout(tt.payout, tt.stake, tt.bonus)
+			if cash != tt.wantCash || bonus != tt.wantBonus {
+				t.Errorf("splitPayout(%s, %s, %s) = %s + %s, want %s + %s",
+					tt.payout, tt.stake, tt.bonus, cash, bonus, tt.wantCash, tt.wantBonus)
+			}
+			if cash+bonus != tt.payout {
+				t.Errorf("paid %s of %s", cash+bonus, tt.payout)
+			}
+		})
+	}
+}
+
+func TestWageringMultiplier(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int64
+	}{
+		{"", defaultWageringMultiplier},
+		{"35", 35},
+		{"0", 0},
+		{"-1", defaultWageringMultiplier},
+		{"1.5", defaultWageringMultiplier},
+		{"twenty", defaultWageringMultiplier},
+	}
+	for _, tt := range tests {
+		t.Setenv("BONUS_WAGERING_MULTIPLIER", tt.env)
+		if got := WageringMultiplier(); got != tt.want {
+			t.Errorf("WageringMultiplier with %q = %d, want %d", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestGrantWagering(t *testing.T) {
+	tests := []struct {
+		name    string
+		grants  []activeGrant
+		stake   money.Amount
+		wagered []money.Amount
+		status  []string
+	}{
+		{
+			name:    "part of the requirement",
+			grants:  []activeGrant{{Remaining: 1000, Required: 20000}},
+			stake:   500,
+			wagered: []money.Amount{500},
+			status:  []string{GrantActive},
+		},
+		{
+			name:    "requirement met",
+			grants:  []activeGrant{{Remaining: 1000, Required: 20000, Wagered: 19800}},
+			stake:   500,
+			wagered: []money.Amount{20000},
+			status:  []string{GrantConverted},
+		},
+		{
+			name: "oldest grant first, the rest to the next",
+			grants: []activeGrant{
+				{Remaining: 1000, Required: 20000, Wagered: 19800},
+				{Remaining: 500, Required: 10000},
+			},
+			stake:   500,
+			wagered: []money.Amount{20000, 300},
+			status:  []string{GrantConverted, GrantActive},
+		},
+		{
+			name: "stake runs out on the first grant",
+			grants: []activeGrant{
+				{Remaining: 1000, Required: 20000},
+				{Remaining: 500, Required: 10000},
+			},
+			stake:   500,
+			wagered: []money.Amount{500, 0},
+			status:  []string{GrantActive, GrantActive},
+		},
+		{
+			name:    "funds spent before the requirement",
+			grants:  []activeGrant{{Remaining: 0, Required: 20000, Wagered: 1000}},
+			stake:   500,
+			wagered: []money.Amount{1500},
+			status:  []string{GrantExhausted},
+		},
+		{
+			name:    "funds spent on the bet that meets the requirement",
+			grants:  []activeGrant{{Remaining: 0, Required: 20000, Wagered: 19500}},
+			stake:   500,
+			wagered: []money.Amount{20000},
+			status:  []string{GrantConverted},
+		},
+		{
+			name:    "no requirement",
+			grants:  []activeGrant{{Remaining: 1000}},
+			stake:   500,
+			wagered: []money.Amount{0},
+			status:  []string{GrantConverted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stake := tt.stake
+			for i := range tt.grants {
+				g := &tt.grants[i]
+				if stake > 0 {
+					stake = g.wager(stake)
+				}
+				if g.Wagered != tt.wagered[i] || g.status() != tt.status[i] {
+					t.Errorf("grant %d wagered %s and is %s, want %s and %s", i, g.Wagered, g.status(), tt.wagered[i], tt.status[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package wallet
+
+import (
+	"casino-hub/backend/money"
+	"errors"
+	"fmt"
+)
+
+// Currencies. Gold coins are free-play coins; sweeps coins are promotional
+// and can be redeemed. users.balance mirrors the gold coin cash balance for
+// older readers.
+const (
+	GoldCoins       = "GC"
+	SweepsCoins     = "SC"
+	DefaultCurrency = GoldCoins
+)
+
+type Currency struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	MinBet money.Amount `json:"minBet"`
+	// MaxBet of zero means no upper limit.
+	MaxBet        money.Amount `json:"maxBet"`
+	Redeemable    bool         `json:"redeemable"`
+	MinRedemption money.Amount `json:"minRedemption,omitempty"`
+	SignupBonus   money.Amount `json:"-"`
+}
+
+var Currencies = map[string]Currency{
+	GoldCoins: {
+		Code:        GoldCoins,
+		Name:        "Gold Coins",
+		MinBet:      money.Coins(1),
+		SignupBonus: money.Coins(5000),
+	},
+	SweepsCoins: {
+		Code:          SweepsCoins,
+		Name:          "Sweeps Coins",
+		MinBet:        money.Coins(1),
+		MaxBet:        money.Coins(500),
+		Redeemable:    true,
+		MinRedemption: money.Coins(100),
+		SignupBonus:   money.Coins(10),
+	},
+}
+
+// CurrencyCodes lists the configured currencies in display order.
+var CurrencyCodes = []string{GoldCoins, SweepsCoins}
+
+var (
+	ErrUnknownCurrency = errors.New("wallet: unknown currency")
+	// ErrRedeemableAdjustment is returned for a manual adjustment in a
+	// redeemable currency.
+	ErrRedeemableAdjustment = errors.New("wallet: adjustments are not allowed in a redeemable currency")
+	ErrStakeOutOfRange      = errors.New("wallet: stake outside the currency limits")
+)
+
+// StakeLimitError is returned when a stake is outside the limits of its
+// currency. It matches ErrStakeOutOfRange with errors.Is.
+type StakeLimitError struct {
+	Currency string
+	Min      money.Amount
+	Max      money.Amount
+}
+
+func (e *StakeLimitError) Error() string {
+	if e.Max > 0 {
+		return fmt.Sprintf("wallet: %s stakes must be between %s and %s", e.Currency, e.Min, e.Max)
+	}
+	return fmt.Sprintf("wallet: %s stakes must be at least %s", e.Currency, e.Min)
+}
+
+func (e *StakeLimitError) Is(target error) bool {
+	return target == ErrStakeOutOfRange
+}
+
+// LookupCurrency resolves a currency code, treating an empty code as the
+// default currency.
+func LookupCurrency(code string) (Currency, error) {
+	if code == "" {
+		code = DefaultCurrency
+	}
+	c, ok := Currencies[code]
+	if !ok {
+		return Currency{}, ErrUnknownCurrency
+	}
+	return c, nil
+}
+
+// CheckStake checks a single bet against the currency's limits.
+func (c Currency) CheckStake(stake money.Amount) error {
+	if stake < c.MinBet || (c.MaxBet > 0 && stake > c.MaxBet) {
+		return &StakeLimitError{Currency: c.Code, Min: c.MinBet, Max: c.MaxBet}
+	}
+	return nil
+}
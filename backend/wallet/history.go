@@ -0,0 +1,160 @@
+package wallet
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/money"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// Filter selects player-side ledger rows. From is inclusive and To is
+// exclusive; zero values leave that bound open.
+type Filter struct {
+	UserID   int
+	Currency string
+	Game     string
+	Type     Type
+	// SubWallet limits the rows to cash or bonus movements.
+	SubWallet string
+	From      time.Time
+	To        time.Time
+	// Cursor is the id of the last row of the previous page.
+	Cursor int64
+	Limit  int
+}
+
+type Page struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   int64         `json:"nextCursor,omitempty"`
+}
+
+// List returns one page of a user's transactions, newest first.
+func List(f Filter) (Page, error) {
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+
+	where, args := f.where()
+	if f.Cursor > 0 {
+		where = append(where, "id < ?")
+		args = append(args, f.Cursor)
+	}
+	args = append(args, f.Limit+1)
+
+	txs, err := queryTransactions(`
+		SELECT id, user_id, type, amount, currency, game, round_id, reason, sub_wallet, balance_after, created_at
+		FROM wallet_transactions
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id DESC
+		LIMIT ?`, args...)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Transactions: txs}
+	if len(txs) > f.Limit {
+		page.Transactions = txs[:f.Limit]
+		page.NextCursor = page.Transactions[f.Limit-1].ID
+	}
+	return page, nil
+}
+
+// Statement returns every transaction in the filter's period, oldest first,
+// together with the balance the period opened with. Statements cover the cash
+// sub-wallet of one currency, gold coins unless the filter says otherwise.
+func Statement(f Filter) (money.Amount, []Transaction, error) {
+	var opening money.Amount
+	if !f.From.IsZero() {
+		err := database.DB.QueryRow(`
+			SELECT balance_after FROM wallet_transactions
+			WHERE user_id = ? AND account = ? AND currency = ? AND sub_wallet = ? AND created_at < ?
+			ORDER BY id DESC LIMIT 1`, f.UserID, PlayerAccount, f.currency(), f.subWallet(), f.From).Scan(&opening)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, nil, err
+		}
+	}
+
+	f.Currency = f.currency()
+	f.SubWallet = f.subWallet()
+	where, args := f.where()
+	txs, err := queryTransactions(`
+		SELECT id, user_id, type, amount, currency, game, round_id, reason, sub_wallet, balance_after, created_at
+		FROM wallet_transactions
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC`, args...)
+	return opening, txs, err
+}
+
+func (f Filter) where() ([]string, []any) {
+	where := []string{"user_id = ?", "account = ?"}
+	args := []any{f.UserID, PlayerAccount}
+	if f.Currency != "" {
+		where = append(where, "currency = ?")
+		args = append(args, f.Currency)
+	}
+	if f.Game != "" {
+		where = append(where, "game = ?")
+		args = append(args, f.Game)
+	}
+	if f.Type != "" {
+		where = append(where, "type = ?")
+		args = append(args, f.Type)
+	}
+	if f.SubWallet != "" {
+		where = append(where, "sub_wallet = ?")
+		args = append(args, f.SubWallet)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, f.To)
+	}
+	return where, args
+}
+
+func (f Filter) currency() string {
+	if f.Currency == "" {
+		return DefaultCurrency
+	}
+	return f.Currency
+}
+
+func (f Filter) subWallet() string {
+	if f.SubWallet == "" {
+		return SubWalletCash
+	}
+	return f.SubWallet
+}
+
+func queryTransactions(query string, args ...any) ([]Transaction, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := []Transaction{}
+	for rows.Next() {
+		var t Transaction
+		var game, roundID sql.NullString
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Type, &t.Amount, &t.Currency, &game, &roundID, &t.Reason, &t.SubWallet, &t.BalanceAfter, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.Game = game.String
+		t.RoundID = roundID.String
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
@@ -0,0 +1,64 @@
+package wallet
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/models"
+	"casino-hub/backend/money"
+	"errors"
+)
+
+const (
+	RedemptionsAccount = "redemptions"
+	ReasonRedemption   = "redemption"
+
+	RedemptionPending = "pending"
+)
+
+var (
+	ErrNotRedeemable      = errors.New("wallet: currency cannot be redeemed")
+	ErrBelowMinRedemption = errors.New("wallet: amount below the minimum redemption")
+)
+
+// Redeem takes amount out of the cash sub-wallet of a redeemable currency
+// and files a pending redemption request for it. Bonus funds have to be
+// converted before they can be redeemed.
+func Redeem(userID int, currency string, amount money.Amount) (models.Redemption, error) {
+	c, err := LookupCurrency(currency)
+	if err != nil {
+		return models.Redemption{}, err
+	}
+	if !c.Redeemable {
+		return models.Redemption{}, ErrNotRedeemable
+	}
+	if amount <= 0 || amount < c.MinRedemption {
+		return models.Redemption{}, ErrBelowMinRedemption
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return models.Redemption{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO redemptions (user_id, currency, amount, status) VALUES (?, ?, ?, ?)", userID, c.Code, amount, RedemptionPending)
+	if err != nil {
+		return models.Redemption{}, err
+	}
+	id, _ := res.LastInsertId()
+
+	t, err := PostTx(tx, Entry{UserID: userID, Type: Debit, Amount: amount, Currency: c.Code, Reason: ReasonRedemption, Contra: RedemptionsAccount})
+	if err != nil {
+		return models.Redemption{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Redemption{}, err
+	}
+	return models.Redemption{
+		ID:        id,
+		Currency:  c.Code,
+		Amount:    amount,
+		Status:    RedemptionPending,
+		Balance:   t.BalanceAfter,
+		CreatedAt: t.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,252 @@
+package wallet
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/money"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("wallet: insufficient funds")
+	ErrInvalidStake      = errors.New("wallet: stake must be positive")
+)
+
+// InsufficientFundsError is returned when a debit would overdraw the player.
+// It matches ErrInsufficientFunds with errors.Is.
+type InsufficientFundsError struct {
+	Balance  money.Amount
+	Required money.Amount
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("wallet: insufficient funds: balance %s, required %s", e.Balance, e.Required)
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+type Bet struct {
+	UserID int
+	Game   string
+	// Currency defaults to DefaultCurrency.
+	Currency string
+	RoundID  string
+	Stake    money.Amount
+	// StakeChecked skips the currency's stake limits. It is set for further
+	// stakes on a round whose opening bet was already checked, such as
+	// insurance or a split, and for stakes made of several bets that were
+	// checked one by one.
+	StakeChecked bool
+	// Check, when set, runs once the player's balance is locked and before
+	// the stake is taken. Returning an error rejects the bet.
+	Check func(tx *sql.Tx, bet Bet) error
+}
+
+type Settlement struct {
+	RoundID      string       `json:"roundId"`
+	Currency     string       `json:"currency"`
+	Stake        money.Amount `json:"stake"`
+	Payout       money.Amount `json:"payout"`
+	Balance      money.Amount `json:"balance"`
+	BonusBalance money.Amount `json:"bonusBalance"`
+}
+
+// Settle is the single settlement path for a game round. Inside one DB
+// transaction it reserves the stake, runs play to decide the outcome and
+// credits the payout play returns (stake included). If anything fails the
+// whole round is rolled back, so the player is never charged for a round that
+// did not happen.
+//
+// The stake is drawn from cash and bonus funds in SpendOrder, winnings go
+// back to the sub-wallets in the same proportion, and the stake counts
+// towards the wagering requirement of active bonus grants.
+//
+// The stake and payout are in bet.Currency, whose stake limits are enforced
+// here.
+//
+// A payout of zero leaves the stake reserved, which is how multi-step games
+// such as blackjack hold the bet until the hand is finished.
+func Settle(bet Bet, play func(tx *sql.Tx) (money.Amount, error)) (Settlement, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return Settlement{}, err
+	}
+	defer tx.Rollback()
+
+	s, err := SettleTx(tx, bet, play)
+	if err != nil {
+		return Settlement{}, err
+	}
+	return s, tx.Commit()
+}
+
+// SettleTx is Settle inside tx, for games that take further stakes on a round
+// they keep state for, such as a blackjack double or split.
+func SettleTx(tx *sql.Tx, bet Bet, play func(tx *sql.Tx) (money.Amount, error)) (Settlement, error) {
+	if bet.Stake <= 0 {
+		return Settlement{}, ErrInvalidStake
+	}
+	currency, err := LookupCurrency(bet.Currency)
+	if err != nil {
+		return Settlement{}, err
+	}
+	if !bet.StakeChecked {
+		if err := currency.CheckStake(bet.Stake); err != nil {
+			return Settlement{}, err
+		}
+	}
+	bet.Currency = currency.Code
+	if bet.RoundID == "" {
+		bet.RoundID = NewRoundID()
+	}
+
+	// The row lock serialises bets of the same user and currency until commit.
+	if err := ensureBalanceRow(tx, bet.UserID, bet.Currency); err != nil {
+		return Settlement{}, err
+	}
+	var cash, bonus money.Amount
+	if err := tx.QueryRow("SELECT balance, bonus_balance FROM wallet_balances WHERE user_id = ? AND currency = ? FOR UPDATE", bet.UserID, bet.Currency).Scan(&cash, &bonus); err != nil {
+		return Settlement{}, err
+	}
+	if cash+bonus < bet.Stake {
+		return Settlement{}, &InsufficientFundsError{Balance: cash + bonus, Required: bet.Stake}
+	}
+	if bet.Check != nil {
+		if err := bet.Check(tx, bet); err != nil {
+			return Settlement{}, err
+		}
+	}
+
+	fromCash, fromBonus := splitStake(bet.Stake, cash, bonus)
+	if err := debitStakeTx(tx, bet, fromCash, fromBonus); err != nil {
+		return Settlement{}, err
+	}
+
+	payout, err := play(tx)
+	if err != nil {
+		return Settlement{}, err
+	}
+	if err := creditPayoutTx(tx, bet.UserID, bet.Game, bet.Currency, bet.RoundID, ReasonWin, payout, bet.Stake, fromBonus); err != nil {
+		return Settlement{}, err
+	}
+	if err := recordWageringTx(tx, bet.UserID, bet.Currency, bet.Stake); err != nil {
+		return Settlement{}, err
+	}
+
+	if err := tx.QueryRow("SELECT balance, bonus_balance FROM wallet_balances WHERE user_id = ? AND currency = ?", bet.UserID, bet.Currency).Scan(&cash, &bonus); err != nil {
+		return Settlement{}, err
+	}
+	return Settlement{RoundID: bet.RoundID, Currency: bet.Currency, Stake: bet.Stake, Payout: payout, Balance: cash, BonusBalance: bonus}, nil
+}
+
+func debitStakeTx(tx *sql.Tx, bet Bet, fromCash, fromBonus money.Amount) error {
+	if fromCash > 0 {
+		if _, err := PostTx(tx, Entry{UserID: bet.UserID, Type: Debit, Amount: fromCash, Currency: bet.Currency, Game: bet.Game, RoundID: bet.RoundID, Reason: ReasonBet}); err != nil {
+			return err
+		}
+	}
+	if fromBonus > 0 {
+		if _, err := PostTx(tx, Entry{UserID: bet.UserID, Type: Debit, Amount: fromBonus, Currency: bet.Currency, Game: bet.Game, RoundID: bet.RoundID, Reason: ReasonBet, SubWallet: SubWalletBonus}); err != nil {
+			return err
+		}
+		if err := consumeBonusTx(tx, bet.UserID, bet.Currency, fromBonus); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func creditPayoutTx(tx *sql.Tx, userID int, game, currency, roundID, reason string, payout, stake, fromBonus money.Amount) error {
+	toCash, toBonus := splitPayout(payout, stake, fromBonus)
+	if toCash > 0 {
+		if _, err := PostTx(tx, Entry{UserID: userID, Type: Credit, Amount: toCash, Currency: currency, Game: game, RoundID: roundID, Reason: reason}); err != nil {
+			return err
+		}
+	}
+	if toBonus > 0 {
+		if _, err := creditBonusTx(tx, Entry{UserID: userID, Type: Credit, Amount: toBonus, Currency: currency, Game: game, RoundID: roundID, Reason: reason}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Payout credits the result of a round whose stake was reserved earlier. The
+// winnings are paid in the currency of the round's stake, split between cash
+// and bonus the way the stake was; currency is only used for rounds without
+// an id.
+func Payout(userID int, game, roundID, currency string, amount money.Amount) (Transaction, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	t, err := PayoutTx(tx, userID, game, roundID, currency, amount)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return t, tx.Commit()
+}
+
+// PayoutTx is Payout inside tx, for games that finish a round together with
+// their own state.
+func PayoutTx(tx *sql.Tx, userID int, game, roundID, currency string, amount money.Amount) (Transaction, error) {
+	return creditRoundTx(tx, userID, game, roundID, currency, ReasonWin, amount)
+}
+
+// RefundTx hands back amount of a round's stake inside tx, for a round that
+// could not be played. Like a payout it goes back to cash and bonus the way
+// the stake was taken.
+func RefundTx(tx *sql.Tx, userID int, game, roundID, currency string, amount money.Amount) (Transaction, error) {
+	return creditRoundTx(tx, userID, game, roundID, currency, ReasonRefund, amount)
+}
+
+func creditRoundTx(tx *sql.Tx, userID int, game, roundID, currency, reason string, amount money.Amount) (Transaction, error) {
+	var stake, fromBonus money.Amount
+	if roundID != "" {
+		var roundCurrency sql.NullString
+		err := tx.QueryRow(`
+			SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(IF(sub_wallet = 'bonus', amount, 0)), 0), MAX(currency)
+			FROM wallet_transactions
+			WHERE user_id = ? AND account = ? AND round_id = ? AND reason = ?`,
+			userID, PlayerAccount, roundID, ReasonBet).Scan(&stake, &fromBonus, &roundCurrency)
+		if err != nil {
+			return Transaction{}, err
+		}
+		if roundCurrency.Valid {
+			currency = roundCurrency.String
+		}
+	}
+	c, err := LookupCurrency(currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	currency = c.Code
+
+	if amount > 0 {
+		if err := creditPayoutTx(tx, userID, game, currency, roundID, reason, amount, stake, fromBonus); err != nil {
+			return Transaction{}, err
+		}
+	}
+
+	balance, _, err := balancesTx(tx, userID, currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return Transaction{UserID: userID, Type: Credit, Amount: amount, Currency: currency, Game: game, RoundID: roundID, Reason: reason, BalanceAfter: balance}, nil
+}
+
+// balancesTx reads the cash and bonus balances of a currency inside tx,
+// creating the row if the user never held that currency.
+func balancesTx(tx *sql.Tx, userID int, currency string) (money.Amount, money.Amount, error) {
+	if err := ensureBalanceRow(tx, userID, currency); err != nil {
+		return 0, 0, err
+	}
+	var cash, bonus money.Amount
+	err := tx.QueryRow("SELECT balance, bonus_balance FROM wallet_balances WHERE user_id = ? AND currency = ?", userID, currency).Scan(&cash, &bonus)
+	return cash, bonus, err
+}
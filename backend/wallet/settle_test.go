@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"casino-hub/backend/money"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestSettleTxRejectsBadBets(t *testing.T) {
+	tests := []struct {
+		name string
+		bet  Bet
+		want error
+	}{
+		{"no stake", Bet{Stake: 0}, ErrInvalidStake},
+		{"negative stake", Bet{Stake: -100}, ErrInvalidStake},
+		{"unknown currency", Bet{Currency: "XX", Stake: 100}, ErrUnknownCurrency},
+		{"below the minimum", Bet{Currency: SweepsCoins, Stake: 99}, ErrStakeOutOfRange},
+		{"above the maximum", Bet{Currency: SweepsCoins, Stake: money.Coins(500) + 1}, ErrStakeOutOfRange},
+		{"below the minimum in the default currency", Bet{Stake: 50}, ErrStakeOutOfRange},
+		// A checked stake skips the limits but not the sign.
+		{"checked stake", Bet{Stake: -1, StakeChecked: true}, ErrInvalidStake},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			played := false
+			// The bet is rejected before the transaction is touched, so a
+			// nil one would panic if it got that far.
+			_, err := SettleTx(nil, tt.bet, func(tx *sql.Tx) (money.Amount, error) {
+				played = true
+				return 0, nil
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if played {
+				t.Error("the round was played")
+			}
+		})
+	}
+}
+
+func TestCheckStake(t *testing.T) {
+	tests := []struct {
+		currency string
+		stake    money.Amount
+		ok       bool
+	}{
+		{GoldCoins, money.Coins(1), true},
+		{GoldCoins, money.Coins(1000000), true},
+		{GoldCoins, 99, false},
+		{SweepsCoins, money.Coins(1), true},
+		{SweepsCoins, money.Coins(500), true},
+		{SweepsCoins, money.Coins(500) + 1, false},
+		{SweepsCoins, 0, false},
+	}
+	for _, tt := range tests {
+		err := Currencies[tt.currency].CheckStake(tt.stake)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s stake of %s: err = %v, want ok = %v", tt.currency, tt.stake, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrStakeOutOfRange) {
+			t.Errorf("%s stake of %s: err = %v, want %v", tt.currency, tt.stake, err, ErrStakeOutOfRange)
+		}
+	}
+}
+
+func TestStakeLimitErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *StakeLimitError
+		want string
+	}{
+		{&StakeLimitError{Currency: "SC", Min: money.Coins(1), Max: money.Coins(500)}, "wallet: SC stakes must be between 1.00 and 500.00"},
+		{&StakeLimitError{Currency: "GC", Min: money.Coins(1)}, "wallet: GC stakes must be at least 1.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestInsufficientFundsError(t *testing.T) {
+	var err error = &InsufficientFundsError{Balance: 250, Required: 1000}
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("%v does not match %v", err, ErrInsufficientFunds)
+	}
+	if errors.Is(err, ErrInvalidStake) {
+		t.Errorf("%v matches %v", err, ErrInvalidStake)
+	}
+	if want := "wallet: insufficient funds: balance 2.50, required 10.00"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,344 @@
+// Package wallet is the double-entry ledger behind every balance change.
+//
+// Each movement is written as two legs in wallet_transactions: one on the
+// player's account and the opposite one on a house account. Balances in
+// wallet_balances (and the gold coin mirror in users.balance) are only a
+// cached projection of the player legs and can be rebuilt at any time.
+package wallet
+
+import (
+	"casino-hub/backend/database"
+	"casino-hub/backend/money"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type Type string
+
+const (
+	Debit  Type = "debit"
+	Credit Type = "credit"
+)
+
+// Accounts. Game legs are booked against "game:<title>" so the house side
+// can be reconciled per game.
+const (
+	PlayerAccount     = "player"
+	HouseAccount      = "house"
+	PromotionsAccount = "promotions"
+)
+
+// Sub-wallets. Promotional funds live in the bonus sub-wallet until their
+// wagering requirement is met; see bonus.go.
+const (
+	SubWalletCash  = "cash"
+	SubWalletBonus = "bonus"
+)
+
+// Reasons recorded on ledger entries.
+const (
+	ReasonBet            = "bet"
+	ReasonWin            = "win"
+	ReasonRefund         = "refund"
+	ReasonSignup         = "signup_bonus"
+	ReasonDailyCash      = "daily_cash"
+	ReasonWheel          = "wheel_spin"
+	ReasonDailyFreeCoins = "daily_free_coins"
+	ReasonAdjustment     = "adjustment"
+	ReasonBonusConvert   = "bonus_conversion"
+)
+
+type Entry struct {
+	UserID int
+	Type   Type
+	Amount money.Amount
+	// Currency defaults to DefaultCurrency.
+	Currency string
+	Game     string
+	RoundID  string
+	Reason   string
+	// SubWallet defaults to SubWalletCash.
+	SubWallet string
+	// Contra is the house-side account; defaults to the game account or
+	// HouseAccount when no game is set.
+	Contra string
+}
+
+type Transaction struct {
+	ID           int64        `json:"id"`
+	UserID       int          `json:"userId"`
+	Type         Type         `json:"type"`
+	Amount       money.Amount `json:"amount"`
+	Currency     string       `json:"currency"`
+	Game         string       `json:"game,omitempty"`
+	RoundID      string       `json:"roundId,omitempty"`
+	Reason       string       `json:"reason"`
+	SubWallet    string       `json:"subWallet"`
+	BalanceAfter money.Amount `json:"balanceAfter"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+func GameAccount(game string) string {
+	return "game:" + game
+}
+
+// NewRoundID returns a random identifier that ties together the legs of a
+// single game round.
+func NewRoundID() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Post records a single movement in its own transaction.
+func Post(e Entry) (Transaction, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	t, err := PostTx(tx, e)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return t, tx.Commit()
+}
+
+// PostTx records a movement inside an existing transaction, updating the
+// cached balance in the same step.
+func PostTx(tx *sql.Tx, e Entry) (Transaction, error) {
+	if e.Amount < 0 {
+		return Transaction{}, fmt.Errorf("wallet: negative amount %s", e.Amount)
+	}
+	if e.Type != Debit && e.Type != Credit {
+		return Transaction{}, fmt.Errorf("wallet: unknown entry type %q", e.Type)
+	}
+	if e.SubWallet == "" {
+		e.SubWallet = SubWalletCash
+	}
+	if e.Currency == "" {
+		e.Currency = DefaultCurrency
+	}
+	c, ok := Currencies[e.Currency]
+	if !ok {
+		return Transaction{}, ErrUnknownCurrency
+	}
+	// Redeemable coins are only ever won or granted, never typed in.
+	if e.Reason == ReasonAdjustment && c.Redeemable {
+		return Transaction{}, ErrRedeemableAdjustment
+	}
+	column, err := balanceColumn(e.SubWallet)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if err := ensureBalanceRow(tx, e.UserID, e.Currency); err != nil {
+		return Transaction{}, err
+	}
+
+	// Debits are conditional on the funds being there, so two concurrent
+	// postings can never take the balance below zero.
+	var res sql.Result
+	if e.Type == Debit {
+		res, err = tx.Exec("UPDATE wallet_balances SET "+column+" = "+column+" - ? WHERE user_id = ? AND currency = ? AND "+column+" >= ?", e.Amount, e.UserID, e.Currency, e.Amount)
+	} else {
+		res, err = tx.Exec("UPDATE wallet_balances SET "+column+" = "+column+" + ? WHERE user_id = ? AND currency = ?", e.Amount, e.UserID, e.Currency)
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var balance money.Amount
+		if err := tx.QueryRow("SELECT "+column+" FROM wallet_balances WHERE user_id = ? AND currency = ?", e.UserID, e.Currency).Scan(&balance); err != nil {
+			return Transaction{}, err
+		}
+		if e.Type == Debit && balance < e.Amount {
+			return Transaction{}, &InsufficientFundsError{Balance: balance, Required: e.Amount}
+		}
+	}
+
+	var balance money.Amount
+	if err := tx.QueryRow("SELECT "+column+" FROM wallet_balances WHERE user_id = ? AND currency = ?", e.UserID, e.Currency).Scan(&balance); err != nil {
+		return Transaction{}, err
+	}
+	if e.Currency == GoldCoins {
+		if _, err := tx.Exec("UPDATE users SET "+column+" = ? WHERE id = ?", balance, e.UserID); err != nil {
+			return Transaction{}, err
+		}
+	}
+
+	contra := e.Contra
+	if contra == "" {
+		contra = HouseAccount
+		if e.Game != "" {
+			contra = GameAccount(e.Game)
+		}
+	}
+	opposite := Credit
+	if e.Type == Credit {
+		opposite = Debit
+	}
+
+	entryID := randomHex(16)
+	now := time.Now()
+	res, err = tx.Exec(`
+		INSERT INTO wallet_transactions (entry_id, account, user_id, type, amount, currency, game, round_id, reason, sub_wallet, balance_after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entryID, PlayerAccount, e.UserID, e.Type, e.Amount, e.Currency, nullString(e.Game), nullString(e.RoundID), e.Reason, e.SubWallet, balance, now,
+	)
+	if err != nil {
+		return Transaction{}, err
+	}
+	id, _ := res.LastInsertId()
+
+	_, err = tx.Exec(`
+		INSERT INTO wallet_transactions (entry_id, account, user_id, type, amount, currency, game, round_id, reason, sub_wallet, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entryID, contra, e.UserID, opposite, e.Amount, e.Currency, nullString(e.Game), nullString(e.RoundID), e.Reason, e.SubWallet, now,
+	)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	return Transaction{
+		ID:           id,
+		UserID:       e.UserID,
+		Type:         e.Type,
+		Amount:       e.Amount,
+		Currency:     e.Currency,
+		Game:         e.Game,
+		RoundID:      e.RoundID,
+		Reason:       e.Reason,
+		SubWallet:    e.SubWallet,
+		BalanceAfter: balance,
+		CreatedAt:    now,
+	}, nil
+}
+
+// ensureBalanceRow creates the user's balance row for a currency on first
+// use. Failing the users foreign key leaves no row, which surfaces as
+// sql.ErrNoRows on the following read.
+func ensureBalanceRow(tx *sql.Tx, userID int, currency string) error {
+	_, err := tx.Exec("INSERT IGNORE INTO wallet_balances (user_id, currency) VALUES (?, ?)", userID, currency)
+	return err
+}
+
+// Balance returns the cached gold coin cash balance of a user.
+func Balance(userID int) (money.Amount, error) {
+	cash, _, err := Balances(userID, GoldCoins)
+	return cash, err
+}
+
+// Balances returns the cached cash and bonus balances of a user in one
+// currency. A currency the user never used has zero balances.
+func Balances(userID int, currency string) (money.Amount, money.Amount, error) {
+	var cash, bonus money.Amount
+	err := database.DB.QueryRow("SELECT balance, bonus_balance FROM wallet_balances WHERE user_id = ? AND currency = ?", userID, currency).Scan(&cash, &bonus)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&exists); err != nil {
+			return 0, 0, err
+		}
+		if exists {
+			return 0, 0, nil
+		}
+	}
+	return cash, bonus, err
+}
+
+func balanceColumn(subWallet string) (string, error) {
+	switch subWallet {
+	case SubWalletCash:
+		return "balance", nil
+	case SubWalletBonus:
+		return "bonus_balance", nil
+	}
+	return "", fmt.Errorf("wallet: unknown sub-wallet %q", subWallet)
+}
+
+const ledgerSums = `
+	SELECT
+		COALESCE(SUM(CASE WHEN sub_wallet = 'cash' THEN IF(type = 'credit', amount, -amount) END), 0),
+		COALESCE(SUM(CASE WHEN sub_wallet = 'bonus' THEN IF(type = 'credit', amount, -amount) END), 0)
+	FROM wallet_transactions
+	WHERE user_id = ? AND currency = ? AND account = ?`
+
+// LedgerBalances sums the player legs of the ledger for a user in one
+// currency, returning the cash and bonus balances.
+func LedgerBalances(userID int, currency string) (money.Amount, money.Amount, error) {
+	var cash, bonus money.Amount
+	err := database.DB.QueryRow(ledgerSums, userID, currency, PlayerAccount).Scan(&cash, &bonus)
+	return cash, bonus, err
+}
+
+// Rebuild recomputes the cached balances of one user in every currency from
+// the ledger.
+func Rebuild(userID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the user row so no posting lands between the sums and the writes.
+	var id int
+	if err := tx.QueryRow("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Scan(&id); err != nil {
+		return err
+	}
+
+	for _, currency := range CurrencyCodes {
+		var cash, bonus money.Amount
+		if err := tx.QueryRow(ledgerSums, userID, currency, PlayerAccount).Scan(&cash, &bonus); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO wallet_balances (user_id, currency, balance, bonus_balance) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE balance = VALUES(balance), bonus_balance = VALUES(bonus_balance)`,
+			userID, currency, cash, bonus)
+		if err != nil {
+			return err
+		}
+		if currency == GoldCoins {
+			if _, err := tx.Exec("UPDATE users SET balance = ?, bonus_balance = ? WHERE id = ?", cash, bonus, userID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// RebuildAll recomputes the cached balances of every user.
+func RebuildAll() error {
+	_, err := database.DB.Exec(`
+		UPDATE wallet_balances b
+		LEFT JOIN (
+			SELECT user_id, currency,
+				SUM(CASE WHEN sub_wallet = 'cash' THEN IF(type = 'credit', amount, -amount) ELSE 0 END) AS cash,
+				SUM(CASE WHEN sub_wallet = 'bonus' THEN IF(type = 'credit', amount, -amount) ELSE 0 END) AS bonus
+			FROM wallet_transactions
+			WHERE account = ?
+			GROUP BY user_id, currency
+		) l ON l.user_id = b.user_id AND l.currency = b.currency
+		SET b.balance = COALESCE(l.cash, 0), b.bonus_balance = COALESCE(l.bonus, 0)`, PlayerAccount)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec(`
+		UPDATE users u
+		LEFT JOIN wallet_balances b ON b.user_id = u.id AND b.currency = ?
+		SET u.balance = COALESCE(b.balance, 0), u.bonus_balance = COALESCE(b.bonus_balance, 0)`, GoldCoins)
+	return err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}